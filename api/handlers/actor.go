@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// ActorHeader es el encabezado con el que los clientes identifican a la persona o
+// sistema que hace un cambio, para registrarlo en el historial.
+const ActorHeader = "X-Actor"
+
+// requestActor retorna el actor indicado en la solicitud o AnonymousActor si no
+// viene.
+func requestActor(r *http.Request) string {
+	actor := strings.TrimSpace(r.Header.Get(ActorHeader))
+	if actor == "" {
+		return domain.AnonymousActor
+	}
+	if len(actor) > 255 {
+		actor = actor[:255]
+	}
+	return actor
+}
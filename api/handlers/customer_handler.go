@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/api/middleware"
+	"github.com/vizardkill/order-management/api/problem"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+type CustomerHandler struct {
+	CustomerService *app.CustomerService
+	Validator       *validator.Validate
+}
+
+type AddressRequest struct {
+	Label      string `json:"label" validate:"max=50"`
+	Line1      string `json:"line1" validate:"required,max=255"`
+	Line2      string `json:"line2" validate:"max=255"`
+	City       string `json:"city" validate:"required,max=100"`
+	State      string `json:"state" validate:"max=100"`
+	PostalCode string `json:"postal_code" validate:"max=20"`
+	Country    string `json:"country" validate:"required,len=2"`
+}
+
+type CreateCustomerRequest struct {
+	Name      string           `json:"name" validate:"required,max=255"`
+	Email     string           `json:"email" validate:"required,email,max=255"`
+	Phone     string           `json:"phone" validate:"omitempty,max=30"`
+	Addresses []AddressRequest `json:"addresses" validate:"dive"`
+}
+
+// PatchCustomerRequest actualiza solo los campos enviados. Si trae addresses, la
+// lista reemplaza todas las direcciones del cliente.
+type PatchCustomerRequest struct {
+	Name      *string           `json:"name" validate:"omitempty,min=1,max=255"`
+	Email     *string           `json:"email" validate:"omitempty,email,max=255"`
+	Phone     *string           `json:"phone" validate:"omitempty,max=30"`
+	Addresses *[]AddressRequest `json:"addresses" validate:"omitempty,dive"`
+}
+
+// ListCustomersQuery contiene los parámetros de consulta validables de GET /customers.
+type ListCustomersQuery struct {
+	Limit int `json:"limit" validate:"min=0,max=100"`
+}
+
+func NewCustomerHandler(customerService *app.CustomerService) *CustomerHandler {
+	return &CustomerHandler{CustomerService: customerService, Validator: app.NewValidator()}
+}
+
+// GET /customers
+func (h *CustomerHandler) ListCustomers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "limit")
+		return
+	}
+
+	// Validar los datos
+	if err := h.Validator.Struct(ListCustomersQuery{Limit: limit}); err != nil {
+		writeQueryError(w, r, app.ValidationError(err))
+		return
+	}
+
+	page, err := h.CustomerService.ListCustomers(ctx, domain.CustomerFilter{
+		Search: query.Get("q"),
+		Limit:  limit,
+		Cursor: query.Get("cursor"),
+	})
+	if err != nil {
+		problem.WriteError(w, r, err, "Error listando los clientes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PageResponse{
+		Data:       page.Customers,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// GET /customers/{customer_id}
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request, customerId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, customerId)
+	if !ok {
+		return
+	}
+
+	customer, err := h.CustomerService.GetCustomerByID(ctx, id)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error obteniendo el cliente")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// GET /customers/{customer_id}/orders
+func (h *CustomerHandler) ListCustomerOrders(w http.ResponseWriter, r *http.Request, customerId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, customerId)
+	if !ok {
+		return
+	}
+
+	filter, err := orderFilterFromQuery(h.Validator, r.URL.Query())
+	if err != nil {
+		writeQueryError(w, r, err)
+		return
+	}
+
+	page, err := h.CustomerService.ListCustomerOrders(ctx, id, filter)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error listando las órdenes del cliente")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PageResponse{
+		Data:       page.Orders,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// POST /customers
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var data CreateCustomerRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
+
+	customer, err := h.CustomerService.CreateCustomer(ctx, domain.CreateCustomerService{
+		IdempotencyKey: middleware.IdempotencyKey(ctx),
+		Name:           data.Name,
+		Email:          data.Email,
+		Phone:          data.Phone,
+		Addresses:      toDomainAddresses(data.Addresses),
+	})
+	if err != nil {
+		problem.WriteError(w, r, err, "Error creando el cliente")
+		return
+	}
+
+	// Serializar la respuesta
+	response, err := json.Marshal(customer)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
+
+// PATCH /customers/{customer_id}
+func (h *CustomerHandler) PatchCustomer(w http.ResponseWriter, r *http.Request, customerId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, customerId)
+	if !ok {
+		return
+	}
+
+	var data PatchCustomerRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
+
+	update := domain.UpdateCustomerService{
+		Name:  data.Name,
+		Email: data.Email,
+		Phone: data.Phone,
+	}
+	if data.Addresses != nil {
+		addresses := toDomainAddresses(*data.Addresses)
+		update.Addresses = &addresses
+	}
+
+	customer, err := h.CustomerService.UpdateCustomer(ctx, id, update)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error actualizando el cliente")
+		return
+	}
+
+	// Serializar la respuesta
+	response, err := json.Marshal(customer)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// DELETE /customers/{customer_id}
+func (h *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request, customerId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, customerId)
+	if !ok {
+		return
+	}
+
+	err := h.CustomerService.DeleteCustomer(ctx, id)
+	if err != nil && !deletedByAbandonedRequest(r, err, domain.ErrCustomerNotFound) {
+		problem.WriteError(w, r, err, "Error eliminando el cliente")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toDomainAddresses convierte las direcciones recibidas en la solicitud al modelo de dominio.
+func toDomainAddresses(addresses []AddressRequest) []domain.Address {
+	result := make([]domain.Address, len(addresses))
+	for i, a := range addresses {
+		result[i] = domain.Address{
+			Label:      a.Label,
+			Line1:      a.Line1,
+			Line2:      a.Line2,
+			City:       a.City,
+			State:      a.State,
+			PostalCode: a.PostalCode,
+			Country:    strings.ToUpper(a.Country),
+		}
+	}
+	return result
+}
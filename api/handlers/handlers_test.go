@@ -0,0 +1,70 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vizardkill/order-management/api/problem"
+	"github.com/vizardkill/order-management/config"
+	"github.com/vizardkill/order-management/internal/container"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// newTestServer crea la aplicación sobre el almacenamiento en memoria.
+func newTestServer(t *testing.T) (*container.Container, http.Handler) {
+	t.Helper()
+
+	c, err := container.NewInMemory(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, c.Server()
+}
+
+// serve envía una solicitud JSON al servidor en inglés. Las rutas que lo exigen
+// reciben una Idempotency-Key propia de la solicitud.
+func serve(t *testing.T, server http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("Idempotency-Key", method+" "+path+" "+body)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeProblem lee el detalle de error de la respuesta.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) problem.Details {
+	t.Helper()
+
+	var details problem.Details
+	if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+		t.Fatal(err)
+	}
+	return details
+}
+
+// createOrder registra un producto con stock y una orden pendiente con una unidad.
+func createOrder(t *testing.T, c *container.Container) domain.Order {
+	t.Helper()
+
+	ctx := context.Background()
+	product, err := c.ProductService.CreateProduct(ctx, domain.CreateProductService{Name: "Teclado", Price: domain.NewMoney(2500, ""), Stock: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := c.OrderService.CreateOrder(ctx, domain.CreateOrderService{
+		CustomerName: "Ana",
+		Items:        []domain.CreateOrderItemService{{ProductID: product.ID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
@@ -0,0 +1,45 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMissingOrdersAndProductsRespondNotFound(t *testing.T) {
+	_, server := newTestServer(t)
+
+	tests := []struct {
+		method, path, body string
+		code, detail       string
+	}{
+		{http.MethodGet, "/orders/99", "", "order_not_found", "Order not found with ID 99"},
+		{http.MethodPost, "/orders/99/cancel", "", "order_not_found", "Order not found with ID 99"},
+		{http.MethodPost, "/orders/99/transitions", `{"status":"paid"}`, "order_not_found", "Order not found with ID 99"},
+		{http.MethodGet, "/products/99", "", "product_not_found", "Product not found with ID 99"},
+		{http.MethodPatch, "/products/99", `{"name":"Nuevo"}`, "product_not_found", "Product not found with ID 99"},
+		{http.MethodDelete, "/products/99", "", "product_not_found", "Product not found with ID 99"},
+		{http.MethodGet, "/products/99/stock-movements", "", "product_not_found", "Product not found with ID 99"},
+	}
+
+	for _, tt := range tests {
+		rec := serve(t, server, tt.method, tt.path, tt.body)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s: %d %s, se esperaba %d", tt.method, tt.path, rec.Code, rec.Body, http.StatusNotFound)
+			continue
+		}
+		if details := decodeProblem(t, rec); details.Code != tt.code || details.Detail != tt.detail {
+			t.Errorf("%s %s: %s %q, se esperaba %s %q", tt.method, tt.path, details.Code, details.Detail, tt.code, tt.detail)
+		}
+	}
+}
+
+func TestInvalidIDsRespondBadRequest(t *testing.T) {
+	_, server := newTestServer(t)
+
+	for _, path := range []string{"/orders/abc", "/products/abc"} {
+		rec := serve(t, server, http.MethodGet, path, "")
+		if details := decodeProblem(t, rec); rec.Code != http.StatusBadRequest || details.Code != "invalid_id" {
+			t.Errorf("GET %s: %d %s, se esperaba %d invalid_id", path, rec.Code, details.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -3,20 +3,18 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
-	"time"
+	"net/url"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/api/middleware"
+	"github.com/vizardkill/order-management/api/problem"
 	"github.com/vizardkill/order-management/internal/app"
 	"github.com/vizardkill/order-management/internal/domain"
-	"github.com/vizardkill/order-management/internal/infrastructure/cache"
 )
 
 type OrderHandler struct {
 	OrderService *app.OrderService
 	Validator    *validator.Validate
-	RedisClient  *cache.RedisClient
 }
 
 type CreateOrderItemRequest struct {
@@ -24,171 +22,261 @@ type CreateOrderItemRequest struct {
 	Quantity  int `json:"quantity" validate:"required,min=1"`
 }
 
+// CreateOrderRequest crea una orden con los items indicados o, si trae
+// reservation_id, con los items de esa reserva. Con customer_id la orden queda
+// asociada al cliente y customer_name pasa a ser opcional.
 type CreateOrderRequest struct {
-	CustomerName string                   `json:"customer_name" validate:"required"`
-	Items        []CreateOrderItemRequest `json:"items" validate:"required,dive,required"`
+	CustomerID    int                      `json:"customer_id" validate:"min=0"`
+	CustomerName  string                   `json:"customer_name" validate:"required_without_all=ReservationID CustomerID"`
+	ReservationID int                      `json:"reservation_id" validate:"min=0"`
+	Items         []CreateOrderItemRequest `json:"items" validate:"required_without=ReservationID,excluded_with=ReservationID,dive,required"`
 }
 
-func NewOrderHandler(orderService *app.OrderService, redisClient *cache.RedisClient) *OrderHandler {
+// AmendOrderRequest contiene los items que debe tener la orden después de
+// modificarla; los productos que no vienen se retiran de la orden.
+type AmendOrderRequest struct {
+	Items []CreateOrderItemRequest `json:"items" validate:"required,min=1,dive,required"`
+}
+
+type TransitionOrderRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending paid shipped delivered cancelled"`
+}
+
+// ListOrdersQuery contiene los parámetros de consulta aceptados por GET /orders.
+type ListOrdersQuery struct {
+	Sort   string `json:"sort" validate:"omitempty,oneof=id -id created_at -created_at total_amount -total_amount"`
+	Status string `json:"status" validate:"omitempty,oneof=pending paid shipped delivered cancelled"`
+	Limit  int    `json:"limit" validate:"min=0,max=100"`
+	Expand string `json:"expand" validate:"omitempty,oneof=items"`
+}
+
+func NewOrderHandler(orderService *app.OrderService) *OrderHandler {
 	return &OrderHandler{
 		OrderService: orderService,
-		Validator:    validator.New(),
-		RedisClient:  redisClient,
+		Validator:    app.NewValidator(),
 	}
 }
 
 // POST /orders
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
-	// Obtener la clave de idempotencia del encabezado
-	idempotencyKey := r.Header.Get("Idempotency-Key")
-	if idempotencyKey == "" {
-		http.Error(w, "Idempotency-Key es requerido", http.StatusBadRequest)
+	ctx := r.Context()
+
+	// Leer el cuerpo de la solicitud
+	var order CreateOrderRequest
+	if !decodeJSON(w, r, h.Validator, &order) {
 		return
 	}
 
-	// Verificar si la clave ya existe en Redis
-	idempotencyData, err := h.RedisClient.GetIdempotencyKey(idempotencyKey)
-	if err != nil && err.Error() != "redis: nil" {
-		http.Error(w, "Error obteniendo la clave de idempotencia", http.StatusInternalServerError)
-		return
+	// Crear la estructura de la orden para el servicio
+	domainOrder := domain.CreateOrderService{
+		Actor:          requestActor(r),
+		IdempotencyKey: middleware.IdempotencyKey(ctx),
+		CustomerID:     order.CustomerID,
+		CustomerName:   order.CustomerName,
+		ReservationID:  order.ReservationID,
+		Items:          make([]domain.CreateOrderItemService, len(order.Items)),
 	}
 
-	if err == nil {
-		if idempotencyData.Status == "IN_PROGRESS" {
-			http.Error(w, "Solicitud en progreso", http.StatusConflict)
-			return
-		}
-		if idempotencyData.Status == "COMPLETED" {
-			// Si ya está completada, devolver la respuesta almacenada
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(idempotencyData.Response))
-			return
+	for i, item := range order.Items {
+		domainOrder.Items[i] = domain.CreateOrderItemService{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
 		}
 	}
 
-	// Marcar la solicitud como IN_PROGRESS en Redis
-	err = h.RedisClient.SetIdempotencyKey(idempotencyKey, cache.IdempotencyData{
-		Status:   "IN_PROGRESS",
-		Response: "",
-	}, 24*time.Hour)
+	// Crear la orden
+	createdOrder, err := h.OrderService.CreateOrder(ctx, domainOrder)
 	if err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+		problem.WriteError(w, r, err, "Error creando la orden")
+		return
+	}
 
-		http.Error(w, "Error configurando la clave de idempotencia", http.StatusInternalServerError)
+	// Serializar la respuesta
+	response, err := json.Marshal(createdOrder)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
 		return
 	}
 
-	// Leer el cuerpo de la solicitud
-	var order CreateOrderRequest
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
+	// Devolver la respuesta
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
 
-	if err := decoder.Decode(&order); err != nil {
-		if delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey); delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+// GET /orders/{order_id}
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request, orderId string) {
+	ctx := r.Context()
 
-		if _, ok := err.(*json.UnmarshalTypeError); ok {
-			http.Error(w, "Error en el formato de los datos enviados: "+err.Error(), http.StatusBadRequest)
-			return
-		}
+	id, ok := parseID(w, r, orderId)
+	if !ok {
+		return
+	}
 
-		if strings.HasPrefix(err.Error(), "json: unknown field") {
-			http.Error(w, "Se enviaron campos no esperados en el cuerpo de la solicitud: "+err.Error(), http.StatusBadRequest)
-			return
-		}
+	order, err := h.OrderService.GetOrderByID(ctx, id)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error obteniendo la orden")
+		return
+	}
 
-		http.Error(w, "Error decodificando la solicitud: "+err.Error(), http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// POST /orders/{order_id}/transitions
+func (h *OrderHandler) TransitionOrder(w http.ResponseWriter, r *http.Request, orderId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, orderId)
+	if !ok {
 		return
 	}
 
-	// Validar los datos
-	if err := h.Validator.Struct(order); err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+	var data TransitionOrderRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
 
-		http.Error(w, "Estructura de datos inválidos: "+err.Error(), http.StatusBadRequest)
+	transition, err := h.OrderService.TransitionOrderStatus(ctx, id, domain.OrderStatus(data.Status), requestActor(r))
+	if err != nil {
+		problem.WriteError(w, r, err, "Error cambiando el estado de la orden")
 		return
 	}
 
-	// Crear la estructura de la orden para el servicio
-	domainOrder := domain.CreateOrderService{
-		CustomerName: order.CustomerName,
-		Items:        make([]domain.CreateOrderItemService, len(order.Items)),
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transition)
+}
+
+// POST /orders/{order_id}/cancel
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request, orderId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, orderId)
+	if !ok {
+		return
 	}
 
-	for i, item := range order.Items {
-		domainOrder.Items[i] = domain.CreateOrderItemService{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-		}
+	if _, err := h.OrderService.CancelOrder(ctx, id, requestActor(r)); err != nil {
+		problem.WriteError(w, r, err, "Error cancelando la orden")
+		return
 	}
 
-	// Crear la orden
-	createdOrder, err := h.OrderService.CreateOrder(domainOrder)
+	order, err := h.OrderService.GetOrderByID(ctx, id)
 	if err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+		problem.WriteError(w, r, err, "Error obteniendo la orden")
+		return
+	}
 
-		http.Error(w, "Error creando la orden: "+err.Error(), http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// PATCH /orders/{order_id}/items
+func (h *OrderHandler) AmendOrderItems(w http.ResponseWriter, r *http.Request, orderId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, orderId)
+	if !ok {
 		return
 	}
 
-	// Serializar la respuesta
-	response, err := json.Marshal(createdOrder)
-	if err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
+	var data AmendOrderRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
+
+	amend := domain.AmendOrderService{
+		Actor: requestActor(r),
+		Items: make([]domain.CreateOrderItemService, len(data.Items)),
+	}
+	for i, item := range data.Items {
+		amend.Items[i] = domain.CreateOrderItemService{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
 		}
+	}
 
-		http.Error(w, "Error procesando la respuesta", http.StatusInternalServerError)
+	if _, err := h.OrderService.AmendOrderItems(ctx, id, amend); err != nil {
+		problem.WriteError(w, r, err, "Error modificando los items de la orden")
 		return
 	}
 
-	// Marcar la solicitud como COMPLETED en Redis
-	err = h.RedisClient.SetIdempotencyKey(idempotencyKey, cache.IdempotencyData{
-		Status:   "COMPLETED",
-		Response: string(response),
-	}, 24*time.Hour)
+	order, err := h.OrderService.GetOrderByID(ctx, id)
 	if err != nil {
-		http.Error(w, "Error almacenando la clave de idempotencia", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Error obteniendo la orden")
 		return
 	}
 
-	// Devolver la respuesta
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	w.Write(response)
+	json.NewEncoder(w).Encode(order)
 }
 
-// GET /orders/{order_id}
-func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request, orderId string) {
-	id, err := strconv.Atoi(orderId)
+// GET /orders
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := orderFilterFromQuery(h.Validator, r.URL.Query())
 	if err != nil {
-		http.Error(w, "ID de orden inválido", http.StatusBadRequest)
+		writeQueryError(w, r, err)
 		return
 	}
 
-	order, err := h.OrderService.GetOrderByID(id)
+	page, err := h.OrderService.ListOrders(ctx, filter)
 	if err != nil {
-		http.Error(w, "Error obteniendo la orden", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Error listando las órdenes")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	json.NewEncoder(w).Encode(PageResponse{
+		Data:       page.Orders,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// orderFilterFromQuery construye el filtro de órdenes a partir de los parámetros
+// de consulta compartidos por GET /orders y GET /customers/{customer_id}/orders.
+// Los errores retornados describen el parámetro inválido.
+func orderFilterFromQuery(validate *validator.Validate, query url.Values) (domain.OrderFilter, error) {
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		return domain.OrderFilter{}, queryParamError{name: "limit"}
+	}
+
+	params := ListOrdersQuery{
+		Sort:   query.Get("sort"),
+		Status: query.Get("status"),
+		Limit:  limit,
+		Expand: query.Get("expand"),
+	}
+
+	// Validar los datos
+	if err := validate.Struct(params); err != nil {
+		return domain.OrderFilter{}, app.ValidationError(err)
+	}
+
+	filter := domain.OrderFilter{
+		CustomerName: query.Get("customer_name"),
+		Status:       domain.OrderStatus(params.Status),
+		Sort:         params.Sort,
+		Limit:        params.Limit,
+		Cursor:       query.Get("cursor"),
+		ExpandItems:  params.Expand == "items",
+	}
+
+	if filter.CreatedFrom, err = queryTime(query, "created_from", false); err != nil {
+		return domain.OrderFilter{}, queryParamError{name: "created_from"}
+	}
+	if filter.CreatedTo, err = queryTime(query, "created_to", true); err != nil {
+		return domain.OrderFilter{}, queryParamError{name: "created_to"}
+	}
+	if filter.MinTotal, err = queryMoney(query, "min_total"); err != nil {
+		return domain.OrderFilter{}, queryParamError{name: "min_total"}
+	}
+	if filter.MaxTotal, err = queryMoney(query, "max_total"); err != nil {
+		return domain.OrderFilter{}, queryParamError{name: "max_total"}
+	}
+
+	return filter, nil
 }
@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestTransitionOrderRejectsInvalidTransitionsWithConflict(t *testing.T) {
+	c, server := newTestServer(t)
+
+	shipped := createOrder(t, c)
+	for _, status := range []string{"paid", "shipped"} {
+		rec := serve(t, server, http.MethodPost, fmt.Sprintf("/orders/%d/transitions", shipped.ID), fmt.Sprintf(`{"status":%q}`, status))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("transición a %s: %d %s", status, rec.Code, rec.Body)
+		}
+	}
+
+	cancelled := createOrder(t, c)
+	if _, err := c.OrderService.CancelOrder(context.Background(), cancelled.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		orderID int
+		status  string
+		detail  string
+	}{
+		{shipped.ID, "pending", "Order status transition not allowed: shipped → pending"},
+		{cancelled.ID, "paid", "Order status transition not allowed: cancelled → paid"},
+	}
+
+	for _, tt := range tests {
+		rec := serve(t, server, http.MethodPost, fmt.Sprintf("/orders/%d/transitions", tt.orderID), fmt.Sprintf(`{"status":%q}`, tt.status))
+		if rec.Code != http.StatusConflict {
+			t.Errorf("%s: %d, se esperaba %d", tt.detail, rec.Code, http.StatusConflict)
+			continue
+		}
+		if details := decodeProblem(t, rec); details.Code != "invalid_order_status_transition" || details.Detail != tt.detail {
+			t.Errorf("respuesta %s %q, se esperaba invalid_order_status_transition %q", details.Code, details.Detail, tt.detail)
+		}
+	}
+}
+
+func TestListOrdersRejectsInvalidQueries(t *testing.T) {
+	_, server := newTestServer(t)
+
+	tests := []struct {
+		query  string
+		status int
+		code   string
+	}{
+		{"limit=101", http.StatusUnprocessableEntity, "validation_failed"},
+		{"limit=-1", http.StatusUnprocessableEntity, "validation_failed"},
+		{"limit=diez", http.StatusBadRequest, "invalid_query_parameter"},
+		{"sort=customer_name", http.StatusUnprocessableEntity, "validation_failed"},
+		{"cursor=alterado", http.StatusUnprocessableEntity, "invalid_cursor"},
+	}
+
+	for _, tt := range tests {
+		rec := serve(t, server, http.MethodGet, "/orders/?"+tt.query, "")
+		if details := decodeProblem(t, rec); rec.Code != tt.status || details.Code != tt.code {
+			t.Errorf("%s: %d %s, se esperaba %d %s", tt.query, rec.Code, details.Code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestGetOrderShowsItemSnapshotAfterCatalogChanges(t *testing.T) {
+	c, server := newTestServer(t)
+	order := createOrder(t, c)
+	productID := order.Items[0].ProductID
+
+	rec := serve(t, server, http.MethodPatch, fmt.Sprintf("/products/%d", productID), `{"name":"Teclado mecánico","price":"40.00"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("actualizar el producto: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(t, server, http.MethodGet, fmt.Sprintf("/orders/%d", order.ID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /orders/%d: %d %s", order.ID, rec.Code, rec.Body)
+	}
+
+	var got domain.Order
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 {
+		t.Fatalf("%d items, se esperaba 1", len(got.Items))
+	}
+	if item := got.Items[0]; item.ProductName != "Teclado" || item.UnitPrice.String() != "25.00" {
+		t.Errorf("item %s a %s, se esperaba Teclado a 25.00", item.ProductName, item.UnitPrice)
+	}
+}
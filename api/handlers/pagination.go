@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// PageResponse es el sobre con el que se responden los listados paginados.
+type PageResponse struct {
+	Data       any    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// queryInt lee un parámetro entero de la consulta; retorna 0 si no viene.
+func queryInt(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// queryBool lee un parámetro booleano opcional de la consulta.
+func queryBool(query url.Values, name string) (*bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// queryMoney lee un monto opcional de la consulta.
+func queryMoney(query url.Values, name string) (*domain.Money, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	v, err := domain.ParseMoney(value, "")
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// queryTime lee una fecha opcional de la consulta en formato RFC 3339 o
+// YYYY-MM-DD. Cuando endOfDay es true, una fecha sin hora se interpreta como el
+// último instante de ese día para que los rangos sean inclusivos.
+func queryTime(query url.Values, name string, endOfDay bool) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
@@ -3,147 +3,303 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
-	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/api/middleware"
+	"github.com/vizardkill/order-management/api/problem"
 	"github.com/vizardkill/order-management/internal/app"
-	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+	"github.com/vizardkill/order-management/internal/domain"
 )
 
 type ProductHandler struct {
 	ProductService *app.ProductService
-	RedisClient    *cache.RedisClient
 	Validator      *validator.Validate
 }
 
 type PutProductStockRequest struct {
-	NewStock int `json:"new_stock" validate:"required,min=0"`
+	NewStock int    `json:"new_stock" validate:"required,min=0"`
+	Reason   string `json:"reason" validate:"omitempty,oneof=manual_adjustment correction"`
+	Note     string `json:"note" validate:"max=255"`
 }
 
-func NewProductHandler(productService *app.ProductService, redisClient *cache.RedisClient) *ProductHandler {
-	return &ProductHandler{ProductService: productService, RedisClient: redisClient, Validator: validator.New()}
+// ListProductsQuery contiene los parámetros de consulta validables de GET /products.
+type ListProductsQuery struct {
+	Sort  string `json:"sort" validate:"omitempty,oneof=id -id name -name price -price stock -stock updated_at -updated_at"`
+	Limit int    `json:"limit" validate:"min=0,max=100"`
+}
+
+// PostStockAdjustmentRequest aplica un cambio relativo al stock: delta positivo
+// para recepciones y negativo para mermas.
+type PostStockAdjustmentRequest struct {
+	Delta  int    `json:"delta" validate:"required"`
+	Reason string `json:"reason" validate:"omitempty,oneof=restock manual_adjustment correction"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+type CreateProductRequest struct {
+	Name  string       `json:"name" validate:"required,max=255"`
+	Price domain.Money `json:"price"`
+	Stock int          `json:"stock" validate:"min=0"`
+}
+
+type PatchProductRequest struct {
+	Name  *string       `json:"name" validate:"omitempty,min=1,max=255"`
+	Price *domain.Money `json:"price"`
+}
+
+func NewProductHandler(productService *app.ProductService) *ProductHandler {
+	return &ProductHandler{ProductService: productService, Validator: app.NewValidator()}
 }
 
 // GET /products
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.ProductService.GetAllProducts()
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	limit, err := queryInt(query, "limit")
 	if err != nil {
-		http.Error(w, "Error obteniendo productos", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "limit")
+		return
+	}
+
+	params := ListProductsQuery{
+		Sort:  query.Get("sort"),
+		Limit: limit,
+	}
+
+	// Validar los datos
+	if err := h.Validator.Struct(params); err != nil {
+		writeQueryError(w, r, app.ValidationError(err))
+		return
+	}
+
+	filter := domain.ProductFilter{
+		Search: query.Get("q"),
+		Sort:   params.Sort,
+		Limit:  params.Limit,
+		Cursor: query.Get("cursor"),
+	}
+
+	if filter.MinPrice, err = queryMoney(query, "min_price"); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "min_price")
+		return
+	}
+	if filter.MaxPrice, err = queryMoney(query, "max_price"); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "max_price")
+		return
+	}
+	if filter.InStock, err = queryBool(query, "in_stock"); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "in_stock")
+		return
+	}
+
+	page, err := h.ProductService.ListProducts(ctx, filter)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error obteniendo productos")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	json.NewEncoder(w).Encode(PageResponse{
+		Data:       page.Products,
+		NextCursor: page.NextCursor,
+		Total:      &page.Total,
+	})
 }
 
-// PUT /products/{product_id}/stock
-func (h *ProductHandler) UpdateProductStock(w http.ResponseWriter, r *http.Request, productId string) {
-	// Obtener la clave de idempotencia del encabezado
-	idempotencyKey := r.Header.Get("Idempotency-Key")
-	if idempotencyKey == "" {
-		http.Error(w, "Idempotency-Key es requerido", http.StatusBadRequest)
+// GET /products/{product_id}
+func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request, productId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, productId)
+	if !ok {
 		return
 	}
 
-	// Verificar si la clave ya existe en Redis
-	idempotencyData, err := h.RedisClient.GetIdempotencyKey(idempotencyKey)
-	if err != nil && err.Error() != "redis: nil" {
-		http.Error(w, "Error obteniendo la clave de idempotencia", http.StatusInternalServerError)
+	product, err := h.ProductService.GetProductByID(ctx, id)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error obteniendo el producto")
 		return
 	}
 
-	if err == nil {
-		if idempotencyData.Status == "IN_PROGRESS" {
-			http.Error(w, "Solicitud en progreso", http.StatusConflict)
-			return
-		}
-		if idempotencyData.Status == "COMPLETED" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// POST /products
+func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var data CreateProductRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
 	}
 
-	// Marcar la solicitud como IN_PROGRESS en Redis
-	err = h.RedisClient.SetIdempotencyKey(idempotencyKey, cache.IdempotencyData{
-		Status:   "IN_PROGRESS",
-		Response: "",
-	}, 24*time.Hour)
+	product, err := h.ProductService.CreateProduct(ctx, domain.CreateProductService{
+		Actor:          requestActor(r),
+		IdempotencyKey: middleware.IdempotencyKey(ctx),
+		Name:           data.Name,
+		Price:          data.Price,
+		Stock:          data.Stock,
+	})
 	if err != nil {
-		http.Error(w, "Error configurando la clave de idempotencia", http.StatusInternalServerError)
+		problem.WriteError(w, r, err, "Error creando el producto")
 		return
 	}
 
-	var data PutProductStockRequest
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
+	// Serializar la respuesta
+	response, err := json.Marshal(product)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
+		return
+	}
 
-	if err := decoder.Decode(&data); err != nil {
-		if delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey); delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
 
-		if _, ok := err.(*json.UnmarshalTypeError); ok {
-			http.Error(w, "Error en el formato de los datos enviados: "+err.Error(), http.StatusBadRequest)
-			return
-		}
+// PATCH /products/{product_id}
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request, productId string) {
+	ctx := r.Context()
 
-		if strings.HasPrefix(err.Error(), "json: unknown field") {
-			http.Error(w, "Se enviaron campos no esperados en el cuerpo de la solicitud: "+err.Error(), http.StatusBadRequest)
-			return
-		}
+	var data PatchProductRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
 
-		http.Error(w, "Error decodificando la solicitud: "+err.Error(), http.StatusBadRequest)
+	id, ok := parseID(w, r, productId)
+	if !ok {
 		return
 	}
 
-	// Validar los datos
-	if err := h.Validator.Struct(data); err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+	product, err := h.ProductService.UpdateProduct(ctx, id, domain.UpdateProductService{
+		Name:  data.Name,
+		Price: data.Price,
+	})
+	if err != nil {
+		problem.WriteError(w, r, err, "Error actualizando el producto")
+		return
+	}
+
+	// Serializar la respuesta
+	response, err := json.Marshal(product)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// DELETE /products/{product_id}
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request, productId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, productId)
+	if !ok {
+		return
+	}
+
+	err := h.ProductService.DeleteProduct(ctx, id)
+	if err != nil && !deletedByAbandonedRequest(r, err, domain.ErrProductNotFound) {
+		problem.WriteError(w, r, err, "Error eliminando el producto")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PUT /products/{product_id}/stock
+func (h *ProductHandler) UpdateProductStock(w http.ResponseWriter, r *http.Request, productId string) {
+	ctx := r.Context()
+
+	var data PutProductStockRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
 
-		http.Error(w, "Estructura de datos inválidos: "+err.Error(), http.StatusBadRequest)
+	id, ok := parseID(w, r, productId)
+	if !ok {
 		return
 	}
 
-	id, err := strconv.Atoi(productId)
+	if err := h.ProductService.UpdateProductStockByID(ctx, id, data.NewStock, domain.StockMovement{
+		Reason: domain.StockMovementReason(data.Reason),
+		Actor:  requestActor(r),
+		Note:   data.Note,
+	}); err != nil {
+		problem.WriteError(w, r, err, "Error actualizando el stock del producto")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /products/{product_id}/stock-movements
+func (h *ProductHandler) GetStockMovements(w http.ResponseWriter, r *http.Request, productId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, productId)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := queryInt(query, "limit")
+	if err != nil || limit < 0 || limit > domain.MaxPageLimit {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, "limit")
+		return
+	}
+
+	page, err := h.ProductService.ListStockMovements(ctx, id, limit, query.Get("cursor"))
 	if err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+		problem.WriteError(w, r, err, "Error obteniendo el historial de stock")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PageResponse{
+		Data:       page.Movements,
+		NextCursor: page.NextCursor,
+	})
+}
 
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+// POST /products/{product_id}/stock/adjustments
+func (h *ProductHandler) AdjustProductStock(w http.ResponseWriter, r *http.Request, productId string) {
+	ctx := r.Context()
+
+	var data PostStockAdjustmentRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
 		return
 	}
 
-	if err := h.ProductService.UpdateProductStockByID(id, data.NewStock); err != nil {
-		delErr := h.RedisClient.DeleteIdempotencyKey(idempotencyKey)
-		if delErr != nil {
-			http.Error(w, "Error eliminando la clave de idempotencia: "+delErr.Error(), http.StatusInternalServerError)
-			return
-		}
+	id, ok := parseID(w, r, productId)
+	if !ok {
+		return
+	}
 
-		http.Error(w, "Error actualizando el stock del producto", http.StatusInternalServerError)
+	movement, err := h.ProductService.AdjustProductStock(ctx, id, data.Delta, domain.StockMovement{
+		Reason:         domain.StockMovementReason(data.Reason),
+		Actor:          requestActor(r),
+		Note:           data.Note,
+		IdempotencyKey: middleware.IdempotencyKey(ctx),
+	})
+	if err != nil {
+		problem.WriteError(w, r, err, "Error ajustando el stock del producto")
 		return
 	}
 
-	// Marcar la solicitud como COMPLETED en Redis
-	err = h.RedisClient.SetIdempotencyKey(idempotencyKey, cache.IdempotencyData{
-		Status:   "COMPLETED",
-		Response: string(productId),
-	}, 24*time.Hour)
+	// Serializar la respuesta
+	response, err := json.Marshal(movement)
 	if err != nil {
-		http.Error(w, "Error almacenando la clave de idempotencia", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
 }
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListProductsRejectsInvalidQueries(t *testing.T) {
+	_, server := newTestServer(t)
+
+	tests := []struct {
+		query  string
+		status int
+		code   string
+	}{
+		{"limit=101", http.StatusUnprocessableEntity, "validation_failed"},
+		{"limit=-1", http.StatusUnprocessableEntity, "validation_failed"},
+		{"limit=diez", http.StatusBadRequest, "invalid_query_parameter"},
+		{"sort=created_at", http.StatusUnprocessableEntity, "validation_failed"},
+		{"min_price=diez", http.StatusBadRequest, "invalid_query_parameter"},
+		{"cursor=alterado", http.StatusUnprocessableEntity, "invalid_cursor"},
+	}
+
+	for _, tt := range tests {
+		rec := serve(t, server, http.MethodGet, "/products/?"+tt.query, "")
+		if details := decodeProblem(t, rec); rec.Code != tt.status || details.Code != tt.code {
+			t.Errorf("%s: %d %s, se esperaba %d %s", tt.query, rec.Code, details.Code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestAdjustStockBelowReservedRespondsConflict(t *testing.T) {
+	c, server := newTestServer(t)
+	order := createOrder(t, c)
+	productID := order.Items[0].ProductID
+
+	rec := serve(t, server, http.MethodPost, fmt.Sprintf("/products/%d/stock/adjustments", productID), `{"delta":-10}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("%d %s, se esperaba %d", rec.Code, rec.Body, http.StatusConflict)
+	}
+
+	want := fmt.Sprintf("Insufficient stock: an adjustment of -10 would leave the stock of product %d below zero or below the reserved quantity", productID)
+	if details := decodeProblem(t, rec); details.Code != "insufficient_stock" || details.Detail != want {
+		t.Errorf("respuesta %s %q, se esperaba insufficient_stock %q", details.Code, details.Detail, want)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/api/middleware"
+	"github.com/vizardkill/order-management/api/problem"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// decodeJSON decodifica el cuerpo de la solicitud en dst rechazando los campos no
+// esperados y lo valida. Si falla responde el error y retorna false: 400 si el
+// cuerpo no es JSON válido y 422 si los datos no pasan la validación.
+func decodeJSON(w http.ResponseWriter, r *http.Request, validate *validator.Validate, dst any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, err.Error())
+			return false
+		}
+
+		if strings.HasPrefix(err.Error(), "json: unknown field") {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeUnknownField, strings.TrimPrefix(err.Error(), "json: unknown field "))
+			return false
+		}
+
+		// Los tipos del dominio, como Money, validan su formato al decodificarse
+		if _, ok := domain.AsError(err); ok {
+			problem.WriteError(w, r, err, "Error decodificando la solicitud")
+			return false
+		}
+
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, err.Error())
+		return false
+	}
+
+	// Validar los datos
+	if err := validate.Struct(dst); err != nil {
+		problem.WriteError(w, r, app.ValidationError(err), "Error validando la solicitud")
+		return false
+	}
+
+	return true
+}
+
+// parseID convierte el ID de la ruta. Si no es un número responde 400 y retorna
+// false.
+func parseID(w http.ResponseWriter, r *http.Request, raw string) (int, bool) {
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidID, raw)
+		return 0, false
+	}
+	return id, true
+}
+
+// queryParamError indica que un parámetro de consulta no tiene el formato esperado.
+type queryParamError struct {
+	name string
+}
+
+func (e queryParamError) Error() string {
+	return "Parámetro " + e.name + " inválido"
+}
+
+// writeQueryError responde un error de los parámetros de consulta: 422 si no
+// pasaron la validación y 400 si no se pudieron interpretar.
+func writeQueryError(w http.ResponseWriter, r *http.Request, err error) {
+	var paramErr queryParamError
+	if errors.As(err, &paramErr) {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidQuery, paramErr.name)
+		return
+	}
+	problem.WriteError(w, r, err, "Parámetros de consulta inválidos")
+}
+
+// deletedByAbandonedRequest indica si err es el no encontrado de un registro que
+// eliminó la solicitud abandonada cuya Idempotency-Key retomó r. El reintento
+// responde entonces como si hubiera eliminado el registro.
+func deletedByAbandonedRequest(r *http.Request, err error, notFoundErr error) bool {
+	return middleware.IdempotencyResumed(r.Context()) && errors.Is(err, notFoundErr)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/api/middleware"
+	"github.com/vizardkill/order-management/api/problem"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+type ReservationHandler struct {
+	ReservationService *app.ReservationService
+	OrderService       *app.OrderService
+	Validator          *validator.Validate
+}
+
+type CreateReservationItemRequest struct {
+	ProductID int `json:"product_id" validate:"required"`
+	Quantity  int `json:"quantity" validate:"required,min=1"`
+}
+
+type CreateReservationRequest struct {
+	CustomerName string                         `json:"customer_name" validate:"required"`
+	TTLMinutes   int                            `json:"ttl_minutes" validate:"omitempty,min=1,max=120"`
+	Items        []CreateReservationItemRequest `json:"items" validate:"required,dive,required"`
+}
+
+func NewReservationHandler(reservationService *app.ReservationService, orderService *app.OrderService) *ReservationHandler {
+	return &ReservationHandler{
+		ReservationService: reservationService,
+		OrderService:       orderService,
+		Validator:          app.NewValidator(),
+	}
+}
+
+// POST /reservations
+func (h *ReservationHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var data CreateReservationRequest
+	if !decodeJSON(w, r, h.Validator, &data) {
+		return
+	}
+
+	reservationData := domain.CreateReservationService{
+		IdempotencyKey: middleware.IdempotencyKey(ctx),
+		CustomerName:   data.CustomerName,
+		TTL:            time.Duration(data.TTLMinutes) * time.Minute,
+		Items:          make([]domain.CreateReservationItemService, len(data.Items)),
+	}
+
+	for i, item := range data.Items {
+		reservationData.Items[i] = domain.CreateReservationItemService{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	reservation, err := h.ReservationService.CreateReservation(ctx, reservationData)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error creando la reserva")
+		return
+	}
+
+	// Serializar la respuesta
+	response, err := json.Marshal(reservation)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
+}
+
+// GET /reservations/{reservation_id}
+func (h *ReservationHandler) GetReservation(w http.ResponseWriter, r *http.Request, reservationId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, reservationId)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.ReservationService.GetReservationByID(ctx, id)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error obteniendo la reserva")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// POST /reservations/{reservation_id}/confirm
+func (h *ReservationHandler) ConfirmReservation(w http.ResponseWriter, r *http.Request, reservationId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, reservationId)
+	if !ok {
+		return
+	}
+
+	// Confirmar una reserva es crear la orden con el stock que tenía retenido
+	order, err := h.OrderService.CreateOrder(ctx, domain.CreateOrderService{
+		Actor:         requestActor(r),
+		ReservationID: id,
+	})
+	if err != nil {
+		problem.WriteError(w, r, err, "Error confirmando la reserva")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// POST /reservations/{reservation_id}/release
+func (h *ReservationHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request, reservationId string) {
+	ctx := r.Context()
+
+	id, ok := parseID(w, r, reservationId)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.ReservationService.ReleaseReservation(ctx, id)
+	if err != nil {
+		problem.WriteError(w, r, err, "Error liberando la reserva")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// ClientIDHeader identifica al cliente cuando la solicitud no trae Authorization.
+const ClientIDHeader = "X-Client-ID"
+
+// idempotencyStoreKey retorna la clave con la que se guarda una Idempotency-Key. Las
+// claves se separan por cliente para que dos clientes que elijan la misma no
+// compartan respuestas; la credencial se guarda como hash y nunca en claro.
+func idempotencyStoreKey(r *http.Request, key string) string {
+	client := r.Header.Get("Authorization")
+	if client == "" {
+		client = r.Header.Get(ClientIDHeader)
+	}
+
+	sum := sha256.Sum256([]byte(client))
+	return "idempotency:" + hex.EncodeToString(sum[:]) + ":" + key
+}
+
+// MaxIdempotentBodyBytes es el tamaño máximo del cuerpo de una solicitud
+// idempotente. El middleware lee el cuerpo completo antes que el manejador, por lo
+// que sin límite un cliente podría hacer que el servidor guarde en memoria un
+// cuerpo arbitrariamente grande.
+const MaxIdempotentBodyBytes = 1 << 20
+
+// requestFingerprint lee el cuerpo de r, lo deja disponible de nuevo para el
+// manejador y retorna un hash del método, la ruta y el cuerpo canónico. Si el
+// cuerpo supera MaxIdempotentBodyBytes retorna un *http.MaxBytesError.
+func requestFingerprint(w http.ResponseWriter, r *http.Request) (string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxIdempotentBodyBytes))
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	h.Write([]byte{0})
+	io.WriteString(h, r.URL.Path)
+	h.Write([]byte{0})
+	h.Write(canonicalBody(body))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalBody normaliza un cuerpo JSON para que el orden de las claves y los
+// espacios no cambien el fingerprint. Los cuerpos que no son JSON se usan tal cual.
+func canonicalBody(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+
+	// json.Marshal ordena las claves de los objetos
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
@@ -0,0 +1,302 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vizardkill/order-management/api/problem"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+)
+
+// IdempotencyKeyTTL es el tiempo durante el que se recuerda una Idempotency-Key.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyLeaseTTL es la duración de la lease de una solicitud en curso. Quien
+// la tiene la renueva cada IdempotencyLeaseTTL/3; si el proceso cae, la lease vence
+// y la solicitud se considera abandonada.
+const IdempotencyLeaseTTL = 15 * time.Second
+
+// maxIdempotencyKeyLength limita la clave, que se guarda junto a los recursos que crea.
+const maxIdempotencyKeyLength = 128
+
+// idempotencyKeyContextKey es la clave de contexto con la Idempotency-Key de la solicitud.
+type idempotencyKeyContextKey struct{}
+
+// idempotencyResumedContextKey marca en el contexto las solicitudes que retomaron
+// la clave de una solicitud abandonada.
+type idempotencyResumedContextKey struct{}
+
+// IdempotencyKey retorna la clave con la que el middleware Idempotency registró la
+// solicitud, ya separada por cliente, o "" si la ruta no es idempotente. Los
+// manejadores la guardan junto a lo que crean para reconciliar reintentos.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// IdempotencyResumed indica si la solicitud retomó la clave de una solicitud
+// abandonada, que pudo aplicar sus cambios antes de caer.
+func IdempotencyResumed(ctx context.Context) bool {
+	resumed, _ := ctx.Value(idempotencyResumedContextKey{}).(bool)
+	return resumed
+}
+
+// Idempotency hace idempotentes las rutas a las que se agrega. Exige el encabezado
+// Idempotency-Key y guarda el código, los encabezados y el cuerpo de la primera
+// respuesta exitosa; las solicitudes repetidas con la misma clave reciben esa
+// respuesta byte a byte sin volver a ejecutar el manejador. Si la solicitud falla
+// la clave se libera para que el cliente pueda reintentarla. Reusar una clave con
+// otro método, ruta o cuerpo responde 422.
+//
+// Si una solicitud en curso deja de renovar su lease (el proceso cayó), un
+// reintento toma la clave y vuelve a ejecutar el manejador, por lo que solo deben
+// agregarlo las rutas que toleran esa repetición:
+//   - las que crean registros o aplican cambios relativos guardan IdempotencyKey
+//     junto a lo que crean y, si ya existe, lo retornan en lugar de repetirlo;
+//   - las que fijan valores absolutos (PATCH, PUT) llegan al mismo estado al
+//     repetirse;
+//   - las que eliminan responden como exitosas si IdempotencyResumed es true y el
+//     registro ya no existe.
+func Idempotency(store app.IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			problem.Write(c.Writer, c.Request, http.StatusBadRequest, problem.CodeIdempotencyKeyRequired)
+			c.Abort()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Write(c.Writer, c.Request, http.StatusBadRequest, problem.CodeIdempotencyKeyTooLong, maxIdempotencyKeyLength)
+			c.Abort()
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c.Writer, c.Request)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Write(c.Writer, c.Request, http.StatusRequestEntityTooLarge, problem.CodeBodyTooLarge, tooLarge.Limit)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			problem.Write(c.Writer, c.Request, http.StatusBadRequest, problem.CodeUnreadableBody)
+			c.Abort()
+			return
+		}
+		key = idempotencyStoreKey(c.Request, key)
+
+		// Las escrituras en el almacén deben completarse aunque el cliente se desconecte
+		ctx := c.Request.Context()
+		storeCtx := context.WithoutCancel(ctx)
+
+		// Marcar la solicitud como IN_PROGRESS solo si la clave no existe
+		lease := newIdempotencyLease(fingerprint)
+		created, err := store.CreateIdempotencyKey(storeCtx, key, lease, IdempotencyKeyTTL)
+		if err != nil {
+			problem.WriteError(c.Writer, c.Request, err, "Error configurando la clave de idempotencia")
+			c.Abort()
+			return
+		}
+
+		if !created {
+			var ok bool
+			lease, ok = respondExisting(c, store, key, fingerprint)
+			if !ok {
+				c.Abort()
+				return
+			}
+		}
+
+		heartbeat := startLeaseHeartbeat(store, key, lease)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		ctx = context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+		ctx = context.WithValue(ctx, idempotencyResumedContextKey{}, !created)
+		c.Request = c.Request.WithContext(ctx)
+
+		// Si el manejador entra en pánico se libera la clave antes de propagarlo
+		defer func() {
+			if p := recover(); p != nil {
+				finishIdempotentRequest(storeCtx, store, key, heartbeat, nil)
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		// Solo se recuerdan las respuestas exitosas
+		status := recorder.Status()
+		if status < 200 || status >= 300 {
+			finishIdempotentRequest(storeCtx, store, key, heartbeat, nil)
+			return
+		}
+
+		// Marcar la solicitud como COMPLETED con la respuesta enviada
+		finishIdempotentRequest(storeCtx, store, key, heartbeat, &cache.IdempotencyData{
+			Status:      cache.IdempotencyStatusCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			Header:      recorder.Header().Clone(),
+			Body:        recorder.body.Bytes(),
+		})
+	}
+}
+
+// respondExisting atiende una solicitud cuya clave ya estaba registrada: rechaza la
+// solicitud si no coincide con la original, repite la respuesta si se completó o
+// informa que sigue en progreso. Si la solicitud original fue abandonada toma su
+// clave y retorna la nueva lease con true para que el manejador se ejecute.
+func respondExisting(c *gin.Context, store app.IdempotencyStore, key, fingerprint string) (cache.IdempotencyData, bool) {
+	data, err := store.GetIdempotencyKey(c.Request.Context(), key)
+	if err != nil && !errors.Is(err, cache.ErrIdempotencyKeyNotFound) {
+		problem.WriteError(c.Writer, c.Request, err, "Error obteniendo la clave de idempotencia")
+		return cache.IdempotencyData{}, false
+	}
+
+	if err == nil && data.Fingerprint != fingerprint {
+		problem.Write(c.Writer, c.Request, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused)
+		return cache.IdempotencyData{}, false
+	}
+
+	if err == nil && isAbandoned(*data) {
+		// Solo uno de los reintentos concurrentes logra reemplazar la lease vencida
+		lease := newIdempotencyLease(fingerprint)
+		taken, err := store.ReplaceIdempotencyKey(context.WithoutCancel(c.Request.Context()), key, *data, lease, IdempotencyKeyTTL)
+		if err != nil {
+			problem.WriteError(c.Writer, c.Request, err, "Error configurando la clave de idempotencia")
+			return cache.IdempotencyData{}, false
+		}
+		if taken {
+			log.Printf("Retomando la solicitud abandonada con la clave de idempotencia %s", key)
+			return lease, true
+		}
+	}
+
+	// La clave pudo liberarse entre ambas lecturas si la solicitud original falló
+	if err != nil || data.Status != cache.IdempotencyStatusCompleted {
+		problem.Write(c.Writer, c.Request, http.StatusConflict, problem.CodeRequestInProgress)
+		return cache.IdempotencyData{}, false
+	}
+
+	header := c.Writer.Header()
+	for name, values := range data.Header {
+		header[name] = values
+	}
+	c.Writer.WriteHeader(data.StatusCode)
+	c.Writer.Write(data.Body)
+	return cache.IdempotencyData{}, false
+}
+
+// newIdempotencyLease crea el estado IN_PROGRESS de una solicitud que comienza.
+func newIdempotencyLease(fingerprint string) cache.IdempotencyData {
+	return cache.IdempotencyData{
+		Status:         cache.IdempotencyStatusInProgress,
+		Fingerprint:    fingerprint,
+		LeaseExpiresAt: time.Now().UTC().Add(IdempotencyLeaseTTL),
+	}
+}
+
+// isAbandoned indica si una solicitud quedó en curso sin que nadie renueve su lease.
+func isAbandoned(data cache.IdempotencyData) bool {
+	return data.Status == cache.IdempotencyStatusInProgress && !time.Now().Before(data.LeaseExpiresAt)
+}
+
+// finishIdempotentRequest detiene la renovación de la lease y guarda completed o,
+// si es nil, libera la clave. Si la lease se perdió no escribe nada, porque la
+// clave ya pertenece a otra solicitud.
+func finishIdempotentRequest(ctx context.Context, store app.IdempotencyStore, key string, heartbeat *leaseHeartbeat, completed *cache.IdempotencyData) {
+	lease, held := heartbeat.Stop()
+	if !held {
+		return
+	}
+
+	var ok bool
+	var err error
+	if completed != nil {
+		ok, err = store.ReplaceIdempotencyKey(ctx, key, lease, *completed, IdempotencyKeyTTL)
+	} else {
+		ok, err = store.ReleaseIdempotencyKey(ctx, key, lease)
+	}
+	if err != nil {
+		log.Printf("Error almacenando la clave de idempotencia %s: %v", key, err)
+		return
+	}
+	if !ok {
+		log.Printf("Se perdió la clave de idempotencia %s antes de terminar la solicitud", key)
+	}
+}
+
+// leaseHeartbeat renueva la lease de una solicitud en curso hasta que se detiene.
+type leaseHeartbeat struct {
+	lease cache.IdempotencyData
+	lost  bool
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func startLeaseHeartbeat(store app.IdempotencyStore, key string, lease cache.IdempotencyData) *leaseHeartbeat {
+	h := &leaseHeartbeat{lease: lease, stop: make(chan struct{}), done: make(chan struct{})}
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(IdempotencyLeaseTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				next := h.lease
+				next.LeaseExpiresAt = time.Now().UTC().Add(IdempotencyLeaseTTL)
+
+				ctx, cancel := context.WithTimeout(context.Background(), IdempotencyLeaseTTL/3)
+				renewed, err := store.ReplaceIdempotencyKey(ctx, key, h.lease, next, IdempotencyKeyTTL)
+				cancel()
+				if err != nil {
+					log.Printf("Error renovando la clave de idempotencia %s: %v", key, err)
+					continue
+				}
+				if !renewed {
+					log.Printf("Se perdió la clave de idempotencia %s antes de terminar la solicitud", key)
+					h.lost = true
+					return
+				}
+				h.lease = next
+			}
+		}
+	}()
+
+	return h
+}
+
+// Stop detiene la renovación y retorna la última lease guardada y si todavía se
+// tiene.
+func (h *leaseHeartbeat) Stop() (cache.IdempotencyData, bool) {
+	close(h.stop)
+	<-h.done
+	return h.lease, !h.lost
+}
+
+// responseRecorder copia el cuerpo de la respuesta mientras se envía al cliente.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vizardkill/order-management/internal/infrastructure/memory"
+)
+
+// newIdempotentRouter registra una ruta idempotente que cuenta sus ejecuciones.
+func newIdempotentRouter(calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders/", Idempotency(memory.NewIdempotencyStore()), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusCreated, gin.H{"id": *calls})
+	})
+	return router
+}
+
+func idempotentRequest(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyRejectsOversizedBodies(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+
+	body := `{"note":"` + strings.Repeat("a", MaxIdempotentBodyBytes) + `"}`
+	rec := idempotentRequest(router, "k1", body)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("código %d, se esperaba 413: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"request_body_too_large"`) {
+		t.Errorf("cuerpo %s, se esperaba el código request_body_too_large", rec.Body.String())
+	}
+	if calls != 0 {
+		t.Errorf("el manejador se ejecutó %d veces", calls)
+	}
+
+	// La clave no queda tomada por la solicitud rechazada
+	if rec := idempotentRequest(router, "k1", `{"note":"a"}`); rec.Code != http.StatusCreated {
+		t.Errorf("reintento con un cuerpo válido: código %d", rec.Code)
+	}
+}
+
+func TestIdempotencyReplaysCompletedResponses(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(&calls)
+
+	first := idempotentRequest(router, "k1", `{"a":1,"b":2}`)
+	// El orden de las claves y los espacios no cambian el fingerprint
+	second := idempotentRequest(router, "k1", `{ "b": 2, "a": 1 }`)
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated || first.Body.String() != second.Body.String() {
+		t.Errorf("respuestas %d %s y %d %s, se esperaba la misma", first.Code, first.Body, second.Code, second.Body)
+	}
+	if calls != 1 {
+		t.Errorf("el manejador se ejecutó %d veces, se esperaba 1", calls)
+	}
+
+	if rec := idempotentRequest(router, "k1", `{"a":2}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("clave reusada con otro cuerpo: código %d, se esperaba 422", rec.Code)
+	}
+}
+
+func TestIdempotencyResumesAbandonedRequests(t *testing.T) {
+	store := memory.NewIdempotencyStore()
+
+	var resumed []bool
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.DELETE("/products/:id", Idempotency(store), func(c *gin.Context) {
+		resumed = append(resumed, IdempotencyResumed(c.Request.Context()))
+		c.Status(http.StatusNoContent)
+	})
+
+	newRequest := func(key string) *http.Request {
+		req := httptest.NewRequest(http.MethodDelete, "/products/1", nil)
+		req.Header.Set("Idempotency-Key", key)
+		return req
+	}
+
+	// Una solicitud que cayó sin terminar deja su lease vencida
+	abandoned := newRequest("k1")
+	fingerprint, err := requestFingerprint(httptest.NewRecorder(), abandoned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lease := newIdempotencyLease(fingerprint)
+	lease.LeaseExpiresAt = time.Now().UTC().Add(-time.Second)
+	if _, err := store.CreateIdempotencyKey(context.Background(), idempotencyStoreKey(abandoned, "k1"), lease, IdempotencyKeyTTL); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"k1", "k2"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, newRequest(key))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("clave %s: código %d, se esperaba 204", key, rec.Code)
+		}
+	}
+
+	if len(resumed) != 2 || !resumed[0] || resumed[1] {
+		t.Errorf("IdempotencyResumed = %v, se esperaba true solo al retomar la clave abandonada", resumed)
+	}
+}
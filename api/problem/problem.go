@@ -0,0 +1,140 @@
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/vizardkill/order-management/internal/domain"
+	"github.com/vizardkill/order-management/internal/i18n"
+)
+
+// ContentType es el tipo de contenido de las respuestas de error (RFC 7807).
+const ContentType = "application/problem+json"
+
+// Códigos de los errores que la API detecta antes de llegar al dominio. Los códigos
+// de los errores del dominio se definen junto a cada error en internal/domain.
+const (
+	CodeInvalidJSON            = "invalid_json"
+	CodeUnknownField           = "unknown_field"
+	CodeUnreadableBody         = "unreadable_body"
+	CodeBodyTooLarge           = "request_body_too_large"
+	CodeInvalidID              = "invalid_id"
+	CodeInvalidQuery           = "invalid_query_parameter"
+	CodeIdempotencyKeyRequired = "idempotency_key_required"
+	CodeIdempotencyKeyTooLong  = "idempotency_key_too_long"
+	CodeIdempotencyKeyReused   = "idempotency_key_reused"
+	CodeRequestInProgress      = "request_in_progress"
+	CodeInternal               = "internal_error"
+)
+
+// Details es el cuerpo de una respuesta de error según RFC 7807. Code identifica el
+// error de forma estable y Errors detalla los campos de un error de validación.
+type Details struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
+
+// Write responde con un error de la API. El detalle es el mensaje de code en el
+// idioma de la solicitud, con args aplicados al formato.
+func Write(w http.ResponseWriter, r *http.Request, status int, code string, args ...any) {
+	lang := Language(r)
+	write(w, lang, newDetails(r, status, code, i18n.Message(lang, code, args...)))
+}
+
+// WriteError responde con el error del dominio contenido en err, descrito en el
+// idioma de la solicitud junto con el contexto que se le agregó con With (el id
+// buscado, los estados de una transición, etc.). Si err no es un error del dominio
+// responde 500 sin exponerlo; err se registra en el log junto a message.
+func WriteError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	domainErr, ok := domain.AsError(err)
+	if !ok {
+		log.Printf("%s %s: %s: %v", r.Method, r.URL.Path, message, err)
+		Write(w, r, http.StatusInternalServerError, CodeInternal)
+		return
+	}
+
+	lang := Language(r)
+
+	// Los códigos sin traducción conservan el mensaje del dominio
+	detail := i18n.Message(lang, domainErr.Code)
+	if detail == "" {
+		detail = err.Error()
+	} else if errDetail, ok := domain.DetailOf(err); ok {
+		detail += localizeDetail(lang, errDetail)
+	}
+
+	details := newDetails(r, StatusOf(domainErr.Kind), domainErr.Code, detail)
+	details.Errors = localizeFields(lang, domainErr.Fields)
+	write(w, lang, details)
+}
+
+// Language retorna el idioma de los mensajes de r según su Accept-Language.
+func Language(r *http.Request) i18n.Language {
+	return i18n.Parse(r.Header.Get("Accept-Language"))
+}
+
+// localizeDetail describe en lang el contexto de un error del dominio. Los
+// contextos sin traducción usan su formato en español.
+func localizeDetail(lang i18n.Language, errDetail domain.Detail) string {
+	if text := i18n.Message(lang, errDetail.Code, errDetail.Args...); text != "" {
+		return text
+	}
+	return fmt.Sprintf(errDetail.Format, errDetail.Args...)
+}
+
+// localizeFields describe cada campo inválido en lang.
+func localizeFields(lang i18n.Language, fields []domain.FieldError) []domain.FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	localized := make([]domain.FieldError, len(fields))
+	for i, field := range fields {
+		field.Message = i18n.FieldMessage(lang, i18n.Rule{Tag: field.Rule, Param: field.Param, Unit: field.Unit})
+		localized[i] = field
+	}
+	return localized
+}
+
+// StatusOf retorna el código de estado HTTP de una clase de error del dominio.
+func StatusOf(kind domain.ErrorKind) int {
+	switch kind {
+	case domain.KindNotFound:
+		return http.StatusNotFound
+	case domain.KindConflict, domain.KindInsufficientStock:
+		return http.StatusConflict
+	case domain.KindValidation:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// newDetails usa el tipo about:blank, por lo que el título es el texto del estado
+// HTTP y el código del error distingue cada caso.
+func newDetails(r *http.Request, status int, code, detail string) Details {
+	return Details{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+func write(w http.ResponseWriter, lang i18n.Language, details Details) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Language", string(lang))
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(details.Status)
+	json.NewEncoder(w).Encode(details)
+}
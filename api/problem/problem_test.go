@@ -0,0 +1,162 @@
+package problem_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vizardkill/order-management/api/problem"
+	"github.com/vizardkill/order-management/config"
+	"github.com/vizardkill/order-management/internal/container"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// writeError responde err con WriteError en el idioma indicado y retorna el detalle.
+func writeError(t *testing.T, err error, lang string) problem.Details {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/99", nil)
+	req.Header.Set("Accept-Language", lang)
+	rec := httptest.NewRecorder()
+	problem.WriteError(rec, req, err, "prueba")
+
+	var details problem.Details
+	if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+		t.Fatal(err)
+	}
+	return details
+}
+
+func TestWriteErrorLocalizesErrorDetail(t *testing.T) {
+	tests := []struct {
+		err  error
+		lang string
+		want string
+	}{
+		{domain.ErrOrderNotFound.With(domain.DetailID(99)), "es", "Orden no encontrada con ID 99"},
+		{domain.ErrOrderNotFound.With(domain.DetailID(99)), "en", "Order not found with ID 99"},
+		{domain.ErrInvalidOrderStatusTransition.With(domain.DetailTransition(domain.OrderStatusPending, domain.OrderStatusDelivered)), "en", "Order status transition not allowed: pending → delivered"},
+		{domain.ErrInsufficientStock.With(domain.DetailProductName("Teclado")), "es", "Stock insuficiente para el producto: Teclado"},
+		// El contexto se conserva aunque el error se envuelva de nuevo
+		{fmt.Errorf("envuelto: %w", domain.ErrReservationNotActive.With(domain.DetailStatus(domain.ReservationStatusExpired))), "en", "The reservation is not active: status expired"},
+		{domain.ErrOrderNotAmendable.With(domain.DetailStatus(domain.OrderStatusCancelled)), "es", "La orden ya no puede modificarse: estado cancelled"},
+		{domain.ErrOrderNotAmendable.With(domain.DetailStatus(domain.OrderStatusShipped)), "en", "The order can no longer be amended: status shipped"},
+		{domain.ErrOrderNotFound, "en", "Order not found"},
+	}
+
+	for _, tt := range tests {
+		if got := writeError(t, tt.err, tt.lang).Detail; got != tt.want {
+			t.Errorf("%v (%s): detalle %q, se esperaba %q", tt.err, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestWriteErrorKeepsDetailWithoutTranslation(t *testing.T) {
+	errUntranslated := domain.NewConflictError("sin_traduccion", "error sin traducción")
+	err := errUntranslated.With(domain.DetailID(7))
+
+	if got := writeError(t, err, "en").Detail; got != "error sin traducción con ID 7" {
+		t.Errorf("detalle %q, se esperaba el mensaje del dominio con su contexto", got)
+	}
+	if !errors.Is(err, errUntranslated) {
+		t.Error("el error con contexto no es el error del dominio")
+	}
+}
+
+func TestResponsesIncludeErrorDetail(t *testing.T) {
+	c, err := container.NewInMemory(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := c.Server()
+
+	product, err := c.ProductService.CreateProduct(context.Background(), domain.CreateProductService{Name: "A", Price: domain.NewMoney(1000, ""), Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := c.OrderService.CreateOrder(context.Background(), domain.CreateOrderService{
+		CustomerName: "Ana",
+		Items:        []domain.CreateOrderItemService{{ProductID: product.ID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{http.MethodGet, "/orders/99", "", http.StatusNotFound, "Order not found with ID 99"},
+		{http.MethodPost, fmt.Sprintf("/orders/%d/transitions", order.ID), `{"status":"delivered"}`, http.StatusConflict, "Order status transition not allowed: pending → delivered"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "en")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		var details problem.Details
+		if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tt.status || details.Detail != tt.want {
+			t.Errorf("%s %s: %d %q, se esperaba %d %q", tt.method, tt.path, rec.Code, details.Detail, tt.status, tt.want)
+		}
+	}
+}
+
+func TestWriteErrorRespondsWithProblemDetails(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{domain.ErrOrderNotFound.With(domain.DetailID(99)), http.StatusNotFound, "order_not_found"},
+		{domain.ErrProductNotFound, http.StatusNotFound, "product_not_found"},
+		{domain.ErrInsufficientStock, http.StatusConflict, "insufficient_stock"},
+		{domain.ErrInvalidOrderStatusTransition, http.StatusConflict, "invalid_order_status_transition"},
+		{domain.ErrInvalidCursor, http.StatusUnprocessableEntity, "invalid_cursor"},
+		{errors.New("conexión rechazada"), http.StatusInternalServerError, problem.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/orders/99", nil)
+		rec := httptest.NewRecorder()
+		problem.WriteError(rec, req, tt.err, "prueba")
+
+		if got := rec.Header().Get("Content-Type"); got != problem.ContentType {
+			t.Errorf("%v: Content-Type %q, se esperaba %q", tt.err, got, problem.ContentType)
+		}
+
+		var details problem.Details
+		if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+			t.Fatal(err)
+		}
+		want := problem.Details{
+			Type:     "about:blank",
+			Title:    http.StatusText(tt.status),
+			Status:   tt.status,
+			Instance: "/orders/99",
+			Code:     tt.code,
+		}
+		if rec.Code != tt.status || details.Type != want.Type || details.Title != want.Title || details.Status != want.Status || details.Instance != want.Instance || details.Code != want.Code {
+			t.Errorf("%v: %d %+v, se esperaba %+v", tt.err, rec.Code, details, want)
+		}
+	}
+}
+
+func TestWriteErrorDoesNotExposeInternalErrors(t *testing.T) {
+	details := writeError(t, errors.New("dial tcp 10.0.0.5:3306: conexión rechazada"), "es")
+
+	if strings.Contains(details.Detail, "10.0.0.5") {
+		t.Errorf("el detalle expone el error interno: %q", details.Detail)
+	}
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vizardkill/order-management/api/handlers"
+)
+
+// RegisterCustomerRoutes configura las rutas relacionadas con clientes.
+func RegisterCustomerRoutes(router *gin.Engine, customerHandler *handlers.CustomerHandler, idempotent gin.HandlerFunc) {
+	// Grupo de rutas para clientes
+	customerRoutes := router.Group("/customers")
+	{
+		customerRoutes.GET("/", func(c *gin.Context) {
+			customerHandler.ListCustomers(c.Writer, c.Request)
+		})
+
+		customerRoutes.POST("/", idempotent, func(c *gin.Context) {
+			customerHandler.CreateCustomer(c.Writer, c.Request)
+		})
+
+		customerRoutes.GET("/:customer_id", func(c *gin.Context) {
+			customerId := c.Param("customer_id")
+			customerHandler.GetCustomer(c.Writer, c.Request, customerId)
+		})
+
+		customerRoutes.PATCH("/:customer_id", idempotent, func(c *gin.Context) {
+			customerId := c.Param("customer_id")
+			customerHandler.PatchCustomer(c.Writer, c.Request, customerId)
+		})
+
+		customerRoutes.DELETE("/:customer_id", idempotent, func(c *gin.Context) {
+			customerId := c.Param("customer_id")
+			customerHandler.DeleteCustomer(c.Writer, c.Request, customerId)
+		})
+
+		customerRoutes.GET("/:customer_id/orders", func(c *gin.Context) {
+			customerId := c.Param("customer_id")
+			customerHandler.ListCustomerOrders(c.Writer, c.Request, customerId)
+		})
+	}
+}
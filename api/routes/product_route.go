@@ -3,23 +3,10 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/vizardkill/order-management/api/handlers"
-	"github.com/vizardkill/order-management/internal/app"
-	"github.com/vizardkill/order-management/internal/infrastructure/cache"
-	"github.com/vizardkill/order-management/internal/infrastructure/database"
-	"github.com/vizardkill/order-management/internal/infrastructure/repo"
 )
 
 // RegisterProductRoutes configura las rutas relacionadas con productos.
-func RegisterProductRoutes(router *gin.Engine, redis *cache.RedisClient) {
-	// Inicializar el repositorio de productos
-	productRepo := repo.NewProductRepository(database.DB)
-
-	// Inicializar el servicio de productos
-	productService := app.NewProductService(productRepo, redis)
-
-	// Inicializar el manejador de productos
-	productHandler := handlers.NewProductHandler(productService, redis)
-
+func RegisterProductRoutes(router *gin.Engine, productHandler *handlers.ProductHandler, idempotent gin.HandlerFunc) {
 	// Grupo de rutas para productos
 	productRoutes := router.Group("/products")
 	{
@@ -27,9 +14,38 @@ func RegisterProductRoutes(router *gin.Engine, redis *cache.RedisClient) {
 			productHandler.GetProducts(c.Writer, c.Request)
 		})
 
-		productRoutes.PUT("/:product_id/stock", func(c *gin.Context) {
+		productRoutes.POST("/", idempotent, func(c *gin.Context) {
+			productHandler.CreateProduct(c.Writer, c.Request)
+		})
+
+		productRoutes.GET("/:product_id", func(c *gin.Context) {
+			productId := c.Param("product_id")
+			productHandler.GetProduct(c.Writer, c.Request, productId)
+		})
+
+		productRoutes.PATCH("/:product_id", idempotent, func(c *gin.Context) {
+			productId := c.Param("product_id")
+			productHandler.PatchProduct(c.Writer, c.Request, productId)
+		})
+
+		productRoutes.DELETE("/:product_id", idempotent, func(c *gin.Context) {
+			productId := c.Param("product_id")
+			productHandler.DeleteProduct(c.Writer, c.Request, productId)
+		})
+
+		productRoutes.PUT("/:product_id/stock", idempotent, func(c *gin.Context) {
 			productId := c.Param("product_id")
 			productHandler.UpdateProductStock(c.Writer, c.Request, productId)
 		})
+
+		productRoutes.POST("/:product_id/stock/adjustments", idempotent, func(c *gin.Context) {
+			productId := c.Param("product_id")
+			productHandler.AdjustProductStock(c.Writer, c.Request, productId)
+		})
+
+		productRoutes.GET("/:product_id/stock-movements", func(c *gin.Context) {
+			productId := c.Param("product_id")
+			productHandler.GetStockMovements(c.Writer, c.Request, productId)
+		})
 	}
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vizardkill/order-management/api/handlers"
+)
+
+// RegisterReservationRoutes configura las rutas relacionadas con reservas de stock.
+func RegisterReservationRoutes(router *gin.Engine, reservationHandler *handlers.ReservationHandler, idempotent gin.HandlerFunc) {
+	// Grupo de rutas para reservas
+	reservationRoutes := router.Group("/reservations")
+	{
+		reservationRoutes.POST("/", idempotent, func(c *gin.Context) {
+			reservationHandler.CreateReservation(c.Writer, c.Request)
+		})
+
+		reservationRoutes.GET("/:reservation_id", func(c *gin.Context) {
+			reservationId := c.Param("reservation_id")
+			reservationHandler.GetReservation(c.Writer, c.Request, reservationId)
+		})
+
+		reservationRoutes.POST("/:reservation_id/confirm", func(c *gin.Context) {
+			reservationId := c.Param("reservation_id")
+			reservationHandler.ConfirmReservation(c.Writer, c.Request, reservationId)
+		})
+
+		reservationRoutes.POST("/:reservation_id/release", func(c *gin.Context) {
+			reservationId := c.Param("reservation_id")
+			reservationHandler.ReleaseReservation(c.Writer, c.Request, reservationId)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vizardkill/order-management/api/handlers"
+	"github.com/vizardkill/order-management/api/middleware"
+	"github.com/vizardkill/order-management/internal/app"
+)
+
+// Dependencies son los manejadores que expone el servidor HTTP y el almacén usado
+// por las rutas idempotentes.
+type Dependencies struct {
+	ProductHandler     *handlers.ProductHandler
+	OrderHandler       *handlers.OrderHandler
+	ReservationHandler *handlers.ReservationHandler
+	CustomerHandler    *handlers.CustomerHandler
+	Idempotency        app.IdempotencyStore
+}
+
+// NewServer construye el router con todas las rutas de la API. No depende de
+// estado global, por lo que puede usarse directamente con httptest.
+func NewServer(deps Dependencies) http.Handler {
+	router := gin.Default()
+
+	// Ruta de prueba para verificar el estado de la API
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "API funcionando correctamente"})
+	})
+
+	// Las rutas que crean, modifican o eliminan productos, órdenes, reservas y
+	// clientes exigen Idempotency-Key. Los cambios de estado (cancelar y cambiar el
+	// estado de una orden, confirmar y liberar una reserva) no la exigen: repetirlos
+	// no vuelve a aplicar el cambio sino que responde 409 Conflict
+	idempotent := middleware.Idempotency(deps.Idempotency)
+
+	// Registrar las rutas de productos
+	RegisterProductRoutes(router, deps.ProductHandler, idempotent)
+
+	// Registrar las rutas de ordenes
+	RegisterOrders(router, deps.OrderHandler, idempotent)
+
+	// Registrar las rutas de reservas
+	RegisterReservationRoutes(router, deps.ReservationHandler, idempotent)
+
+	// Registrar las rutas de clientes
+	RegisterCustomerRoutes(router, deps.CustomerHandler, idempotent)
+
+	return router
+}
+
+// NewAdminServer construye el router de administración con las métricas de expvar,
+// entre ellas las estrategias de lock usadas. Publica también la línea de comandos
+// y el uso de memoria del proceso, por lo que debe servirse en una dirección
+// interna y no junto a la API.
+func NewAdminServer() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return mux
+}
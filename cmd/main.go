@@ -1,42 +1,131 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/gin-gonic/gin"
-	"github.com/vizardkill/order-management/api/routes"
 	"github.com/vizardkill/order-management/config"
+	"github.com/vizardkill/order-management/internal/container"
 	"github.com/vizardkill/order-management/internal/infrastructure/cache"
 	"github.com/vizardkill/order-management/internal/infrastructure/database"
 )
 
+// shutdownTimeout es el tiempo máximo que se espera a que terminen las
+// solicitudes en curso al apagar el servidor.
+const shutdownTimeout = 15 * time.Second
+
 func main() {
+	// Cancelar el contexto al recibir SIGINT o SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Cargar configuración
 	cfg := config.LoadConfig()
 
-	// Inicializar MySQL y Redis
-	database.InitMySQL(cfg)
+	// Inicializar MySQL
+	db, err := database.OpenMySQL(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
 
-	// Inicializar el router
-	router := gin.Default()
+	// Subcomando migrate: aplica, revierte o lista migraciones y termina
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, db, os.Args[2:]); err != nil {
+			log.Fatalf("Error ejecutando migrate: %v", err)
+		}
+		return
+	}
+
+	// Aplicar las migraciones pendientes antes de atender solicitudes
+	if cfg.DBAutoMigrate {
+		if err := runMigrate(ctx, db, []string{"up"}); err != nil {
+			log.Fatalf("Error ejecutando las migraciones: %v", err)
+		}
+	}
 
 	// Inicializar Redis
 	redisClient := cache.NewRedisClient(cfg.RedisHost, cfg.RedisPort, cfg.RedisPass, cfg.RedisDB)
+	defer redisClient.Client.Close()
+
+	// Construir el contenedor de dependencias
+	c, err := container.New(cfg, db, redisClient)
+	if err != nil {
+		log.Fatalf("Error construyendo la aplicación: %v", err)
+	}
 
-	// Ruta de prueba para verificar el estado de la API
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "API funcionando correctamente"})
-	})
+	// Liberar periódicamente las reservas cuyo tiempo expiró hasta el apagado
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		c.ReservationService.RunExpirationSweeper(ctx, time.Minute)
+	}()
 
-	// Registrar las rutas de productos
-	routes.RegisterProductRoutes(router, redisClient)
+	// Verificar Redis para degradar los locks y la idempotencia a la base de datos
+	// si no responde
+	go func() {
+		defer workers.Done()
+		c.RedisHealth.Run(ctx, 5*time.Second)
+	}()
 
-	// Registrar las rutas de ordenes
-	routes.RegisterOrders(router, redisClient)
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: c.Server(),
+	}
 
 	// Iniciar servidor
-	log.Println("Servidor corriendo en el puerto 8080")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Error al iniciar el servidor: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Println("Servidor corriendo en el puerto 8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	// Iniciar el servidor de administración con las métricas en una dirección interna
+	adminServer := &http.Server{
+		Addr:    cfg.AdminAddr,
+		Handler: c.AdminServer(),
+	}
+	if cfg.AdminAddr != "" {
+		go func() {
+			log.Println("Servidor de administración corriendo en " + cfg.AdminAddr)
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Error en el servidor de administración: %v", err)
+			}
+		}()
 	}
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Fatalf("Error al iniciar el servidor: %v", err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Dejar de aceptar conexiones y esperar a que terminen las solicitudes en curso
+	log.Println("Apagando el servidor...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error apagando el servidor: %v", err)
+	}
+	if err := adminServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error apagando el servidor de administración: %v", err)
+	}
+	workers.Wait()
+
+	log.Println("Servidor detenido")
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/vizardkill/order-management/internal/infrastructure/database"
+)
+
+const migrateUsage = `Uso:
+  main migrate up        aplica todas las migraciones pendientes
+  main migrate down [N]  revierte las últimas N migraciones aplicadas (por defecto 1)
+  main migrate status    muestra el estado de cada migración`
+
+// runMigrate ejecuta el subcomando migrate con los argumentos recibidos.
+func runMigrate(ctx context.Context, db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("falta la acción de migrate\n%s", migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Aplicada %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("No hay migraciones pendientes")
+		}
+		return nil
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+				return fmt.Errorf("N debe ser un entero mayor que cero\n%s", migrateUsage)
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, n)
+		for _, m := range reverted {
+			fmt.Printf("Revertida %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No hay migraciones aplicadas")
+		}
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSIÓN\tNOMBRE\tESTADO\tAPLICADA")
+		for _, s := range statuses {
+			state, appliedAt := "pendiente", "-"
+			if s.Applied {
+				state = "aplicada"
+				appliedAt = s.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state = "modificada"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("acción de migrate desconocida: %s\n%s", args[0], migrateUsage)
+	}
+}
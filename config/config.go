@@ -18,6 +18,14 @@ type Config struct {
 	RedisPort  string
 	RedisPass  string
 	RedisDB    int
+	// DBAutoMigrate aplica las migraciones pendientes al iniciar el servidor.
+	DBAutoMigrate bool
+	// LockStrategy elige cómo se serializan los cambios de stock: "redis" (con
+	// degradación automática a la base de datos si Redis falla) o "database".
+	LockStrategy string
+	// AdminAddr es la dirección del servidor de administración con las métricas;
+	// vacía lo desactiva.
+	AdminAddr string
 }
 
 func LoadConfig() *Config {
@@ -42,6 +50,16 @@ func LoadConfig() *Config {
 			}
 			return val
 		}(),
+		DBAutoMigrate: func() bool {
+			val, err := strconv.ParseBool(getEnv("DB_AUTO_MIGRATE", "true"))
+			if err != nil {
+				log.Printf("Error converting DB_AUTO_MIGRATE to bool: %v. Using default value true.", err)
+				return true
+			}
+			return val
+		}(),
+		LockStrategy: getEnv("LOCK_STRATEGY", "redis"),
+		AdminAddr:    getEnv("ADMIN_ADDR", "127.0.0.1:8081"),
 	}
 }
 
@@ -0,0 +1,67 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vizardkill/order-management/config"
+	"github.com/vizardkill/order-management/internal/container"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// newTestContainer crea la aplicación sobre el almacenamiento en memoria, con
+// datos propios para cada prueba.
+func newTestContainer(t *testing.T) *container.Container {
+	t.Helper()
+
+	c, err := container.NewInMemory(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// createProduct registra un producto con el precio en texto y el stock indicados.
+func createProduct(t *testing.T, c *container.Container, name, price string, stock int) domain.Product {
+	t.Helper()
+
+	amount, err := domain.ParseMoney(price, domain.DefaultCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := c.ProductService.CreateProduct(context.Background(), domain.CreateProductService{
+		Name:  name,
+		Price: amount,
+		Stock: stock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+// assertStock comprueba el stock físico y retenido de un producto.
+func assertStock(t *testing.T, c *container.Container, productID, stock, reserved int) {
+	t.Helper()
+
+	product, err := c.ProductService.GetProductByID(context.Background(), productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Stock != stock || product.Reserved != reserved {
+		t.Errorf("producto %d: stock %d y reservado %d, se esperaba %d y %d", productID, product.Stock, product.Reserved, stock, reserved)
+	}
+}
+
+// stockMovements retorna los movimientos de stock de un producto, del más reciente
+// al más antiguo.
+func stockMovements(t *testing.T, c *container.Container, productID int) []domain.StockMovement {
+	t.Helper()
+
+	page, err := c.ProductService.ListStockMovements(context.Background(), productID, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page.Movements
+}
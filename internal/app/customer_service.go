@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+type CustomerService struct {
+	CustomerRepo CustomerRepository
+	OrderRepo    OrderRepository
+	Validate     *validator.Validate
+}
+
+func NewCustomerService(customerRepo CustomerRepository, orderRepo OrderRepository) *CustomerService {
+	return &CustomerService{CustomerRepo: customerRepo, OrderRepo: orderRepo, Validate: NewValidator()}
+}
+
+// CreateCustomer valida y registra un nuevo cliente con sus direcciones. Si ya
+// existe un cliente creado con la misma IdempotencyKey se retorna ese cliente.
+func (s *CustomerService) CreateCustomer(ctx context.Context, customer domain.CreateCustomerService) (domain.Customer, error) {
+	// Validar datos
+	if err := s.Validate.Struct(customer); err != nil {
+		return domain.Customer{}, ValidationError(err)
+	}
+
+	return createIdempotent(ctx, customer.IdempotencyKey, domain.ErrCustomerNotFound, s.CustomerRepo.GetCustomerByIdempotencyKey, func() (domain.Customer, error) {
+		return s.CustomerRepo.CreateCustomer(ctx, domain.Customer{
+			Name:           customer.Name,
+			Email:          customer.Email,
+			Phone:          customer.Phone,
+			Addresses:      customer.Addresses,
+			IdempotencyKey: customer.IdempotencyKey,
+		})
+	})
+}
+
+// GetCustomerByID obtiene un cliente por su id incluyendo sus direcciones.
+func (s *CustomerService) GetCustomerByID(ctx context.Context, customerID int) (domain.Customer, error) {
+	return s.CustomerRepo.GetCustomerByID(ctx, customerID)
+}
+
+// ListCustomers retorna una página de clientes que cumplen el filtro.
+func (s *CustomerService) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error) {
+	filter.Limit = domain.NormalizeLimit(filter.Limit)
+
+	return s.CustomerRepo.ListCustomers(ctx, filter)
+}
+
+// UpdateCustomer actualiza los datos de contacto y/o las direcciones de un cliente.
+func (s *CustomerService) UpdateCustomer(ctx context.Context, customerID int, customer domain.UpdateCustomerService) (domain.Customer, error) {
+	// Validar datos
+	if err := s.Validate.Struct(customer); err != nil {
+		return domain.Customer{}, ValidationError(err)
+	}
+
+	return s.CustomerRepo.UpdateCustomer(ctx, customerID, customer)
+}
+
+// DeleteCustomer elimina un cliente sin órdenes asociadas.
+func (s *CustomerService) DeleteCustomer(ctx context.Context, customerID int) error {
+	return s.CustomerRepo.DeleteCustomer(ctx, customerID)
+}
+
+// ListCustomerOrders retorna el historial de órdenes de un cliente con los mismos
+// filtros y paginación del listado general. Por defecto las órdenes más recientes
+// aparecen primero.
+func (s *CustomerService) ListCustomerOrders(ctx context.Context, customerID int, filter domain.OrderFilter) (domain.OrderPage, error) {
+	// Verificar que el cliente exista
+	if _, err := s.CustomerRepo.GetCustomerByID(ctx, customerID); err != nil {
+		return domain.OrderPage{}, err
+	}
+
+	filter.CustomerID = customerID
+	if filter.Sort == "" {
+		filter.Sort = "-created_at"
+	}
+	filter.Limit = domain.NormalizeLimit(filter.Limit)
+
+	return s.OrderRepo.ListOrders(ctx, filter)
+}
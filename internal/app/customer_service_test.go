@@ -0,0 +1,34 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestCreateCustomerWithIdempotencyKeyReturnsExistingCustomer(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+
+	data := domain.CreateCustomerService{IdempotencyKey: "k1", Name: "Ana", Email: "ana@example.com"}
+	first, err := c.CustomerService.CreateCustomer(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sin la clave el mismo email chocaría con el cliente ya creado
+	second, err := c.CustomerService.CreateCustomer(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("el reintento retornó el cliente %d, se esperaba %d", second.ID, first.ID)
+	}
+
+	data.IdempotencyKey = "k2"
+	if _, err := c.CustomerService.CreateCustomer(ctx, data); !errors.Is(err, domain.ErrCustomerEmailTaken) {
+		t.Errorf("error %v, se esperaba %v", err, domain.ErrCustomerEmailTaken)
+	}
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// createIdempotent ejecuta create salvo que una solicitud anterior con la misma
+// clave ya haya creado el registro, en cuyo caso retorna ese registro. Así el
+// reintento de una solicitud interrumpida después de confirmar la transacción no
+// crea un duplicado. find debe retornar notFoundErr si ningún registro tiene la
+// clave. Con key vacía siempre se ejecuta create.
+func createIdempotent[T any](ctx context.Context, key string, notFoundErr error, find func(ctx context.Context, key string) (T, error), create func() (T, error)) (T, error) {
+	if key != "" {
+		existing, err := find(ctx, key)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, notFoundErr) {
+			var zero T
+			return zero, err
+		}
+	}
+
+	created, err := create()
+
+	// Otra solicitud con la misma clave creó el registro mientras tanto
+	if key != "" && errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		return find(ctx, key)
+	}
+	return created, err
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+)
+
+var _ IdempotencyStore = (*FallbackIdempotencyStore)(nil)
+
+// FallbackIdempotencyStore usa Primary mientras Health la reporte disponible y
+// Fallback en caso contrario, igual que FallbackLockStrategy. Un error de Primary
+// la marca como no disponible y la operación se repite sobre Fallback.
+//
+// Las claves registradas en un almacén siguen vigentes cuando se cambia al otro:
+// una clave existente en cualquiera de los dos no puede crearse de nuevo, y la
+// lectura, el reemplazo y la liberación buscan la clave en ambos.
+type FallbackIdempotencyStore struct {
+	Primary  IdempotencyStore
+	Fallback IdempotencyStore
+	Health   *HealthMonitor
+}
+
+// NewFallbackIdempotencyStore crea el almacén con degradación a fallback.
+func NewFallbackIdempotencyStore(primary, fallback IdempotencyStore, health *HealthMonitor) *FallbackIdempotencyStore {
+	return &FallbackIdempotencyStore{Primary: primary, Fallback: fallback, Health: health}
+}
+
+func (s *FallbackIdempotencyStore) GetIdempotencyKey(ctx context.Context, key string) (*cache.IdempotencyData, error) {
+	active, other := s.stores()
+
+	data, err := active.GetIdempotencyKey(ctx, key)
+	if s.failedOver(ctx, active, err) {
+		return s.Fallback.GetIdempotencyKey(ctx, key)
+	}
+	if !errors.Is(err, cache.ErrIdempotencyKeyNotFound) || other == nil {
+		return data, err
+	}
+	return other.GetIdempotencyKey(ctx, key)
+}
+
+func (s *FallbackIdempotencyStore) CreateIdempotencyKey(ctx context.Context, key string, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	active, other := s.stores()
+
+	// La clave pudo registrarse en el otro almacén antes del último cambio
+	if other != nil {
+		_, err := other.GetIdempotencyKey(ctx, key)
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, cache.ErrIdempotencyKeyNotFound) {
+			return false, err
+		}
+	}
+
+	created, err := active.CreateIdempotencyKey(ctx, key, data, expiration)
+	if s.failedOver(ctx, active, err) {
+		return s.Fallback.CreateIdempotencyKey(ctx, key, data, expiration)
+	}
+	return created, err
+}
+
+func (s *FallbackIdempotencyStore) ReplaceIdempotencyKey(ctx context.Context, key string, old, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	active, other := s.stores()
+
+	replaced, err := active.ReplaceIdempotencyKey(ctx, key, old, data, expiration)
+	if s.failedOver(ctx, active, err) {
+		return s.Fallback.ReplaceIdempotencyKey(ctx, key, old, data, expiration)
+	}
+	if err != nil || replaced || other == nil {
+		return replaced, err
+	}
+	return other.ReplaceIdempotencyKey(ctx, key, old, data, expiration)
+}
+
+func (s *FallbackIdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key string, old cache.IdempotencyData) (bool, error) {
+	active, other := s.stores()
+
+	released, err := active.ReleaseIdempotencyKey(ctx, key, old)
+	if s.failedOver(ctx, active, err) {
+		return s.Fallback.ReleaseIdempotencyKey(ctx, key, old)
+	}
+	if err != nil || released || other == nil {
+		return released, err
+	}
+	return other.ReleaseIdempotencyKey(ctx, key, old)
+}
+
+// stores retorna el almacén activo y el otro almacén que puede tener claves; other
+// es nil mientras Primary no está disponible, porque no puede consultarse.
+func (s *FallbackIdempotencyStore) stores() (active, other IdempotencyStore) {
+	if !s.Health.Healthy() {
+		return s.Fallback, nil
+	}
+	return s.Primary, s.Fallback
+}
+
+// failedOver indica si la operación debe repetirse sobre Fallback porque Primary
+// falló. En ese caso marca Primary como no disponible.
+func (s *FallbackIdempotencyStore) failedOver(ctx context.Context, active IdempotencyStore, err error) bool {
+	if err == nil || active != s.Primary || errors.Is(err, cache.ErrIdempotencyKeyNotFound) || ctx.Err() != nil {
+		return false
+	}
+
+	s.Health.MarkUnhealthy(err)
+	return true
+}
@@ -0,0 +1,42 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+	"github.com/vizardkill/order-management/internal/infrastructure/memory"
+)
+
+func TestFallbackIdempotencyStoreKeepsKeysAfterRecovery(t *testing.T) {
+	ctx := context.Background()
+	primary, fallback := memory.NewIdempotencyStore(), memory.NewIdempotencyStore()
+	lease := cache.IdempotencyData{Status: cache.IdempotencyStatusInProgress, Fingerprint: "f"}
+
+	// Durante la caída la clave se registra en el almacén de respaldo
+	down := app.NewHealthMonitor("idempotencia-prueba", nil)
+	down.MarkUnhealthy(errors.New("caído"))
+	created, err := app.NewFallbackIdempotencyStore(primary, fallback, down).CreateIdempotencyKey(ctx, "k", lease, time.Minute)
+	if err != nil || !created {
+		t.Fatalf("creación durante la caída: %v %v", created, err)
+	}
+
+	// Al recuperarse la clave sigue tomada y su dueño puede completarla
+	store := app.NewFallbackIdempotencyStore(primary, fallback, app.NewHealthMonitor("idempotencia-prueba", nil))
+	if created, err := store.CreateIdempotencyKey(ctx, "k", lease, time.Minute); err != nil || created {
+		t.Errorf("la clave registrada durante la caída se creó de nuevo: %v %v", created, err)
+	}
+
+	completed := cache.IdempotencyData{Status: cache.IdempotencyStatusCompleted, Fingerprint: "f", StatusCode: 201}
+	if replaced, err := store.ReplaceIdempotencyKey(ctx, "k", lease, completed, time.Minute); err != nil || !replaced {
+		t.Fatalf("reemplazo tras la recuperación: %v %v", replaced, err)
+	}
+
+	got, err := store.GetIdempotencyKey(ctx, "k")
+	if err != nil || got.StatusCode != 201 {
+		t.Errorf("lectura tras la recuperación: %+v %v", got, err)
+	}
+}
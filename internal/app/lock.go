@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// LockPolicy define cuánto dura un lock y cómo se reintenta su adquisición.
+type LockPolicy struct {
+	// TTL es la expiración del lock. Mientras se mantiene tomado se extiende cada
+	// TTL/3, por lo que solo expira si el proceso que lo tiene deja de responder.
+	TTL time.Duration
+	// WaitTimeout es el tiempo máximo que se espera a que otro propietario lo libere.
+	WaitTimeout time.Duration
+	// RetryDelay es la espera antes del primer reintento; se duplica en cada
+	// intento hasta MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// DefaultLockPolicy es la política usada por los servicios.
+var DefaultLockPolicy = LockPolicy{
+	TTL:           5 * time.Second,
+	WaitTimeout:   3 * time.Second,
+	RetryDelay:    25 * time.Millisecond,
+	MaxRetryDelay: 400 * time.Millisecond,
+}
+
+// productLockKey es la clave del lock que protege el stock de un producto.
+func productLockKey(productID int) string {
+	return fmt.Sprintf("lock:product:%d", productID)
+}
+
+// productLockKeys retorna las claves de lock de varios productos.
+func productLockKeys(productIDs []int) []string {
+	keys := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		keys[i] = productLockKey(id)
+	}
+	return keys
+}
+
+// acquireLockUntil toma el lock de key reintentando con backoff exponencial y
+// jitter mientras otro propietario lo tenga, hasta deadline o hasta que se cancele
+// ctx. El lock retornado se extiende en segundo plano hasta que se libera.
+func acquireLockUntil(ctx context.Context, locker Locker, policy LockPolicy, key string, deadline time.Time) (*heldLock, error) {
+	delay := policy.RetryDelay
+
+	for {
+		lock, err := locker.TryLock(ctx, key, policy.TTL)
+		if err == nil {
+			return holdLock(lock, policy.TTL), nil
+		}
+		if !errors.Is(err, domain.ErrLockNotAcquired) {
+			return nil, err
+		}
+
+		// Esperar un tiempo aleatorio entre delay/2 y delay para no reintentar
+		// todos a la vez
+		wait := delay/2 + rand.N(delay/2+1)
+		if time.Now().Add(wait).After(deadline) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, policy.MaxRetryDelay)
+	}
+}
+
+// acquireLocks toma todos los locks de keys o ninguno. Las claves se deduplican y
+// se ordenan, de modo que dos solicitudes que comparten claves las piden en el
+// mismo orden y no pueden quedar esperándose mutuamente. policy.WaitTimeout limita
+// la espera total; si un lock no se obtiene a tiempo se liberan los ya tomados.
+func acquireLocks(ctx context.Context, locker Locker, policy LockPolicy, keys []string) (*lockSet, error) {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	deadline := time.Now().Add(policy.WaitTimeout)
+	set := &lockSet{}
+	for _, key := range keys {
+		lock, err := acquireLockUntil(ctx, locker, policy, key, deadline)
+		if err != nil {
+			set.Release(context.WithoutCancel(ctx))
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		set.locks = append(set.locks, lock)
+	}
+
+	return set, nil
+}
+
+// lockProducts toma los locks del stock de todos los productos indicados o de
+// ninguno. Los ids repetidos se toman una sola vez.
+func lockProducts(ctx context.Context, locker Locker, policy LockPolicy, productIDs []int) (*lockSet, error) {
+	set, err := acquireLocks(ctx, locker, policy, productLockKeys(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo adquirir el lock para los productos: %w", err)
+	}
+	return set, nil
+}
+
+// lockSet es un conjunto de locks tomados juntos por acquireLocks.
+type lockSet struct {
+	locks []*heldLock
+}
+
+// HoldsAll indica si el conjunto incluye los locks de todas las claves.
+func (s *lockSet) HoldsAll(keys []string) bool {
+	for _, key := range keys {
+		if !slices.ContainsFunc(s.locks, func(l *heldLock) bool { return l.lock.Key() == key }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Release libera los locks en orden inverso al que se tomaron. Igual que
+// heldLock.Release, debe recibir un contexto que no se cancele con la solicitud.
+func (s *lockSet) Release(ctx context.Context) error {
+	var errs []error
+	for i := len(s.locks) - 1; i >= 0; i-- {
+		errs = append(errs, s.locks[i].Release(ctx))
+	}
+	s.locks = nil
+	return errors.Join(errs...)
+}
+
+// heldLock mantiene un lock tomado extendiendo su expiración periódicamente, de
+// modo que las transacciones largas no lo pierdan a mitad de camino.
+type heldLock struct {
+	lock domain.Lock
+	stop chan struct{}
+	done chan struct{}
+}
+
+func holdLock(lock domain.Lock, ttl time.Duration) *heldLock {
+	h := &heldLock{lock: lock, stop: make(chan struct{}), done: make(chan struct{})}
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+				err := lock.Extend(ctx, ttl)
+				cancel()
+				if errors.Is(err, domain.ErrLockNotHeld) {
+					log.Printf("Se perdió el lock %s antes de liberarlo", lock.Key())
+					return
+				}
+				if err != nil {
+					log.Printf("Error extendiendo el lock %s: %v", lock.Key(), err)
+				}
+			}
+		}
+	}()
+
+	return h
+}
+
+// Release detiene la extensión y libera el lock. Debe recibir un contexto que no se
+// cancele junto con la solicitud (context.WithoutCancel); de lo contrario el lock
+// queda tomado hasta expirar.
+func (h *heldLock) Release(ctx context.Context) error {
+	close(h.stop)
+	<-h.done
+
+	if err := h.lock.Release(ctx); err != nil {
+		log.Printf("Error liberando el lock %s: %v", h.lock.Key(), err)
+		return err
+	}
+	return nil
+}
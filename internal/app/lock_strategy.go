@@ -0,0 +1,245 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// Nombres de las estrategias de lock, usados en la configuración y en las métricas.
+const (
+	LockStrategyRedis    = "redis"
+	LockStrategyDatabase = "database"
+)
+
+// lockMetrics cuenta en /debug/vars cuántas solicitudes atendió cada estrategia y
+// cuántas veces se degradó de Redis a la base de datos.
+var lockMetrics = expvar.NewMap("lock_strategy")
+
+// LockStrategy serializa las mutaciones de stock de un conjunto de productos. Los
+// servicios llaman a LockProducts antes de abrir la transacción y a
+// ProductLocks.LockInTx dentro de ella con los productos que van a modificar.
+type LockStrategy interface {
+	Name() string
+	LockProducts(ctx context.Context, productIDs []int) (ProductLocks, error)
+}
+
+// ProductLocks son los locks tomados por una solicitud.
+type ProductLocks interface {
+	// LockInTx asegura, dentro de la transacción, que los productos están
+	// bloqueados para esta solicitud.
+	LockInTx(ctx context.Context, tx domain.Tx, productIDs []int) error
+	// Release debe recibir un contexto que no se cancele con la solicitud.
+	Release(ctx context.Context) error
+}
+
+// RedisLockStrategy toma locks distribuidos antes de abrir la transacción, de modo
+// que las transacciones nunca esperan locks.
+type RedisLockStrategy struct {
+	Locker Locker
+	Policy LockPolicy
+}
+
+// NewRedisLockStrategy crea la estrategia con DefaultLockPolicy.
+func NewRedisLockStrategy(locker Locker) *RedisLockStrategy {
+	return &RedisLockStrategy{Locker: locker, Policy: DefaultLockPolicy}
+}
+
+func (s *RedisLockStrategy) Name() string {
+	return LockStrategyRedis
+}
+
+func (s *RedisLockStrategy) LockProducts(ctx context.Context, productIDs []int) (ProductLocks, error) {
+	set, err := lockProducts(ctx, s.Locker, s.Policy, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lockMetrics.Add(s.Name(), 1)
+	return redisProductLocks{set: set}, nil
+}
+
+type redisProductLocks struct {
+	set *lockSet
+}
+
+// LockInTx verifica que los locks tomados cubran todos los productos; los items
+// de una orden pudieron cambiar entre la lectura y la transacción.
+func (l redisProductLocks) LockInTx(ctx context.Context, tx domain.Tx, productIDs []int) error {
+	if !l.set.HoldsAll(productLockKeys(productIDs)) {
+		return domain.ErrLockNotAcquired.With(domain.DetailLocksChanged())
+	}
+	return nil
+}
+
+func (l redisProductLocks) Release(ctx context.Context) error {
+	return l.set.Release(ctx)
+}
+
+// DatabaseLockStrategy no usa Redis: bloquea las filas de los productos con
+// SELECT ... FOR UPDATE dentro de la transacción. Las operaciones sobre un solo
+// producto que no abren una transacción en el servicio quedan protegidas por las
+// sentencias condicionadas y los FOR UPDATE del propio repositorio.
+type DatabaseLockStrategy struct {
+	ProductRepo ProductRepository
+}
+
+// NewDatabaseLockStrategy crea la estrategia sobre el repositorio de productos.
+func NewDatabaseLockStrategy(productRepo ProductRepository) *DatabaseLockStrategy {
+	return &DatabaseLockStrategy{ProductRepo: productRepo}
+}
+
+func (s *DatabaseLockStrategy) Name() string {
+	return LockStrategyDatabase
+}
+
+func (s *DatabaseLockStrategy) LockProducts(ctx context.Context, productIDs []int) (ProductLocks, error) {
+	lockMetrics.Add(s.Name(), 1)
+	return databaseProductLocks{repo: s.ProductRepo}, nil
+}
+
+type databaseProductLocks struct {
+	repo ProductRepository
+}
+
+// LockInTx bloquea las filas en orden de id para que dos transacciones con los
+// mismos productos no se esperen mutuamente.
+func (l databaseProductLocks) LockInTx(ctx context.Context, tx domain.Tx, productIDs []int) error {
+	ids := slices.Clone(productIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	return l.repo.LockProductsWithTransaction(ctx, tx, ids)
+}
+
+func (l databaseProductLocks) Release(ctx context.Context) error {
+	return nil
+}
+
+// FallbackLockStrategy usa Primary mientras Health la reporte disponible y Fallback
+// en caso contrario. Un error de infraestructura de Primary (no la contención) la
+// marca como no disponible y la solicitud continúa con Fallback.
+type FallbackLockStrategy struct {
+	Primary  LockStrategy
+	Fallback LockStrategy
+	Health   *HealthMonitor
+}
+
+// Name retorna la estrategia activa: Fallback mientras Primary no está disponible.
+func (s *FallbackLockStrategy) Name() string {
+	if !s.Health.Healthy() {
+		return s.Fallback.Name()
+	}
+	return s.Primary.Name()
+}
+
+func (s *FallbackLockStrategy) LockProducts(ctx context.Context, productIDs []int) (ProductLocks, error) {
+	if !s.Health.Healthy() {
+		lockMetrics.Add("fallbacks", 1)
+		return s.Fallback.LockProducts(ctx, productIDs)
+	}
+
+	locks, err := s.Primary.LockProducts(ctx, productIDs)
+	if err == nil || errors.Is(err, domain.ErrLockNotAcquired) || ctx.Err() != nil {
+		return locks, err
+	}
+
+	s.Health.MarkUnhealthy(err)
+	lockMetrics.Add("fallbacks", 1)
+	return s.Fallback.LockProducts(ctx, productIDs)
+}
+
+// HealthMonitor verifica periódicamente una dependencia y recuerda si está
+// disponible. Su estado se publica en /debug/vars.
+type HealthMonitor struct {
+	Name    string
+	Check   func(ctx context.Context) error
+	healthy atomic.Bool
+}
+
+// healthMetrics publica 1 si la dependencia está disponible y 0 si no.
+var healthMetrics = expvar.NewMap("health")
+
+// NewHealthMonitor crea un monitor que inicialmente considera la dependencia
+// disponible.
+func NewHealthMonitor(name string, check func(ctx context.Context) error) *HealthMonitor {
+	m := &HealthMonitor{Name: name, Check: check}
+	m.setHealthy(true)
+	return m
+}
+
+// Healthy indica si la última verificación fue exitosa.
+func (m *HealthMonitor) Healthy() bool {
+	return m.healthy.Load()
+}
+
+// MarkUnhealthy marca la dependencia como no disponible hasta la próxima
+// verificación exitosa.
+func (m *HealthMonitor) MarkUnhealthy(err error) {
+	if m.healthy.Load() {
+		log.Printf("%s no disponible: %v", m.Name, err)
+	}
+	m.setHealthy(false)
+}
+
+// Run verifica la dependencia cada interval hasta que ctx se cancela.
+func (m *HealthMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, interval/2)
+		err := m.Check(checkCtx)
+		cancel()
+
+		if err != nil {
+			m.MarkUnhealthy(err)
+			continue
+		}
+		if !m.healthy.Load() {
+			log.Printf("%s disponible nuevamente", m.Name)
+		}
+		m.setHealthy(true)
+	}
+}
+
+func (m *HealthMonitor) setHealthy(healthy bool) {
+	m.healthy.Store(healthy)
+
+	v := new(expvar.Int)
+	if healthy {
+		v.Set(1)
+	}
+	healthMetrics.Set(m.Name, v)
+}
+
+// NewLockStrategy crea la estrategia indicada en la configuración. Con "redis" se
+// degrada automáticamente a la base de datos mientras health reporte Redis caído;
+// health nil desactiva la degradación.
+func NewLockStrategy(name string, locker Locker, productRepo ProductRepository, health *HealthMonitor) (LockStrategy, error) {
+	switch name {
+	case LockStrategyDatabase:
+		return NewDatabaseLockStrategy(productRepo), nil
+	case LockStrategyRedis, "":
+		redis := NewRedisLockStrategy(locker)
+		if health == nil {
+			return redis, nil
+		}
+		return &FallbackLockStrategy{Primary: redis, Fallback: NewDatabaseLockStrategy(productRepo), Health: health}, nil
+	default:
+		return nil, fmt.Errorf("estrategia de lock desconocida: %s", name)
+	}
+}
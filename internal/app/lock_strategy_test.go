@@ -0,0 +1,26 @@
+package app_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/infrastructure/memory"
+)
+
+func TestFallbackLockStrategyNameReportsActiveStrategy(t *testing.T) {
+	health := app.NewHealthMonitor("locks-prueba", nil)
+	strategy, err := app.NewLockStrategy(app.LockStrategyRedis, memory.NewLocker(), memory.NewProductRepository(memory.NewStore()), health)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strategy.Name(); got != app.LockStrategyRedis {
+		t.Errorf("con Redis disponible: %q, se esperaba %q", got, app.LockStrategyRedis)
+	}
+
+	health.MarkUnhealthy(errors.New("caído"))
+	if got := strategy.Name(); got != app.LockStrategyDatabase {
+		t.Errorf("con Redis caído: %q, se esperaba %q", got, app.LockStrategyDatabase)
+	}
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// fakeLocker registra los intentos y las liberaciones. Las claves de busy están
+// tomadas por otro propietario durante la cantidad de intentos indicada (-1 para
+// siempre) y las de fail fallan con ese error.
+type fakeLocker struct {
+	mu       sync.Mutex
+	busy     map[string]int
+	fail     map[string]error
+	attempts []string
+	times    []time.Time
+	held     map[string]bool
+	released []string
+}
+
+func newFakeLocker() *fakeLocker {
+	return &fakeLocker{busy: map[string]int{}, fail: map[string]error{}, held: map[string]bool{}}
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.attempts = append(l.attempts, key)
+	l.times = append(l.times, time.Now())
+	if err, ok := l.fail[key]; ok {
+		return nil, err
+	}
+	if remaining := l.busy[key]; remaining != 0 {
+		if remaining > 0 {
+			l.busy[key]--
+		}
+		return nil, domain.ErrLockNotAcquired
+	}
+
+	l.held[key] = true
+	return &fakeLock{locker: l, key: key}, nil
+}
+
+type fakeLock struct {
+	locker *fakeLocker
+	key    string
+}
+
+func (l *fakeLock) Key() string {
+	return l.key
+}
+
+func (l *fakeLock) Extend(ctx context.Context, ttl time.Duration) error {
+	return nil
+}
+
+func (l *fakeLock) Release(ctx context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+
+	delete(l.locker.held, l.key)
+	l.locker.released = append(l.locker.released, l.key)
+	return nil
+}
+
+// testLockPolicy reintenta rápido para que las pruebas no esperen segundos.
+var testLockPolicy = LockPolicy{
+	TTL:           time.Second,
+	WaitTimeout:   200 * time.Millisecond,
+	RetryDelay:    10 * time.Millisecond,
+	MaxRetryDelay: 40 * time.Millisecond,
+}
+
+func TestLockProductsSortsAndDeduplicatesKeys(t *testing.T) {
+	locker := newFakeLocker()
+
+	set, err := lockProducts(context.Background(), locker, testLockPolicy, []int{3, 1, 3, 2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"lock:product:1", "lock:product:2", "lock:product:3"}
+	if !slices.Equal(locker.attempts, want) {
+		t.Errorf("intentos %v, se esperaba %v", locker.attempts, want)
+	}
+	if !set.HoldsAll(productLockKeys([]int{2, 3, 1})) {
+		t.Error("el conjunto no incluye todos los locks")
+	}
+
+	// Se liberan en orden inverso al que se tomaron
+	if err := set.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(locker.released, []string{"lock:product:3", "lock:product:2", "lock:product:1"}) {
+		t.Errorf("liberados %v, se esperaba el orden inverso", locker.released)
+	}
+}
+
+func TestLockProductsReleasesTakenLocksWhenOneFails(t *testing.T) {
+	errDown := errors.New("redis no responde")
+
+	tests := []struct {
+		name    string
+		prepare func(l *fakeLocker)
+		want    error
+	}{
+		{"tomado por otro", func(l *fakeLocker) { l.busy["lock:product:3"] = -1 }, domain.ErrLockNotAcquired},
+		{"error de infraestructura", func(l *fakeLocker) { l.fail["lock:product:3"] = errDown }, errDown},
+	}
+
+	for _, tt := range tests {
+		locker := newFakeLocker()
+		tt.prepare(locker)
+
+		_, err := lockProducts(context.Background(), locker, testLockPolicy, []int{1, 2, 3})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, se esperaba %v", tt.name, err, tt.want)
+		}
+		if len(locker.held) != 0 {
+			t.Errorf("%s: quedaron tomados %v", tt.name, locker.held)
+		}
+		if !slices.Equal(locker.released, []string{"lock:product:2", "lock:product:1"}) {
+			t.Errorf("%s: liberados %v, se esperaban los dos ya tomados", tt.name, locker.released)
+		}
+	}
+}
+
+func TestAcquireLockUntilBacksOffExponentially(t *testing.T) {
+	locker := newFakeLocker()
+	locker.busy["k"] = 4
+
+	lock, err := acquireLockUntil(context.Background(), locker, testLockPolicy, "k", time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release(context.Background())
+
+	if len(locker.times) != 5 {
+		t.Fatalf("%d intentos, se esperaban 5", len(locker.times))
+	}
+
+	// Cada espera es al menos la mitad del retraso, que se duplica hasta el máximo
+	delays := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, delay := range delays {
+		if wait := locker.times[i+1].Sub(locker.times[i]); wait < delay/2 {
+			t.Errorf("espera %d de %v, se esperaba al menos %v", i+1, wait, delay/2)
+		}
+	}
+}
+
+func TestAcquireLocksGivesUpAfterWaitTimeout(t *testing.T) {
+	locker := newFakeLocker()
+	locker.busy["lock:product:1"] = -1
+
+	start := time.Now()
+	_, err := lockProducts(context.Background(), locker, testLockPolicy, []int{1})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, domain.ErrLockNotAcquired) {
+		t.Fatalf("error %v, se esperaba %v", err, domain.ErrLockNotAcquired)
+	}
+	if elapsed > testLockPolicy.WaitTimeout+100*time.Millisecond {
+		t.Errorf("se esperó %v, el límite es %v", elapsed, testLockPolicy.WaitTimeout)
+	}
+	if len(locker.attempts) < 2 {
+		t.Errorf("%d intentos, se esperaba que reintentara", len(locker.attempts))
+	}
+}
+
+func TestAcquireLocksStopsWhenContextIsCancelled(t *testing.T) {
+	locker := newFakeLocker()
+	locker.busy["lock:product:2"] = -1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	_, err := lockProducts(ctx, locker, testLockPolicy, []int{1, 2})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v, se esperaba %v", err, context.DeadlineExceeded)
+	}
+	if len(locker.held) != 0 {
+		t.Errorf("quedaron tomados %v", locker.held)
+	}
+}
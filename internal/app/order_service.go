@@ -1,7 +1,7 @@
 package app
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"time"
 
@@ -9,81 +9,170 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/vizardkill/order-management/internal/domain"
-	"github.com/vizardkill/order-management/internal/infrastructure/cache"
-	"github.com/vizardkill/order-management/internal/infrastructure/repo"
 )
 
 type OrderService struct {
-	OrderRepo   *repo.OrderRepository
-	ProductRepo *repo.ProductRepository
-	RedisClient *cache.RedisClient
-	Validate    *validator.Validate
+	OrderRepo       OrderRepository
+	ProductRepo     ProductRepository
+	ReservationRepo ReservationRepository
+	CustomerRepo    CustomerRepository
+	Locks           LockStrategy
+	Validate        *validator.Validate
 }
 
-func NewOrderService(orderRepo *repo.OrderRepository, productRepo *repo.ProductRepository, redisClient *cache.RedisClient) *OrderService {
+func NewOrderService(orderRepo OrderRepository, productRepo ProductRepository, reservationRepo ReservationRepository, customerRepo CustomerRepository, locks LockStrategy) *OrderService {
 	return &OrderService{
-		OrderRepo:   orderRepo,
-		ProductRepo: productRepo,
-		RedisClient: redisClient,
-		Validate:    validator.New(),
+		OrderRepo:       orderRepo,
+		ProductRepo:     productRepo,
+		ReservationRepo: reservationRepo,
+		CustomerRepo:    customerRepo,
+		Locks:           locks,
+		Validate:        NewValidator(),
 	}
 }
 
-// CreateOrder crea una nueva orden y reduce el stock de los productos.
-func (s *OrderService) CreateOrder(order domain.CreateOrderService) (domain.Order, error) {
+// CreateOrder crea una nueva orden y reduce el stock de los productos. Si la orden
+// indica una reserva, los items y el stock retenido se toman de esa reserva. Si
+// indica un cliente, la orden queda asociada a él y toma su nombre por defecto.
+func (s *OrderService) CreateOrder(ctx context.Context, order domain.CreateOrderService) (domain.Order, error) {
 	// Validar datos
 	if err := s.Validate.Struct(order); err != nil {
-		return domain.Order{}, errors.New("Estructura de datos inválidos: " + err.Error())
+		return domain.Order{}, ValidationError(err)
 	}
 
-	var orderData domain.Order
-	var totalAmount float64
+	// Una solicitud interrumpida pudo crear la orden antes de responder; su reintento
+	// recibe esa orden en lugar de crear otra
+	if order.IdempotencyKey != "" {
+		existing, err := s.OrderRepo.GetOrderByIdempotencyKey(ctx, order.IdempotencyKey)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, domain.ErrOrderNotFound) {
+			return domain.Order{}, err
+		}
+	}
 
-	// Obtener los productos de la base de datos
-	for _, item := range order.Items {
-		product, err := s.ProductRepo.GetProductByID(item.ProductID)
+	if order.CustomerID != 0 {
+		customer, err := s.CustomerRepo.GetCustomerByID(ctx, order.CustomerID)
+		if err != nil {
+			return domain.Order{}, err
+		}
+
+		if order.CustomerName == "" {
+			order.CustomerName = customer.Name
+		}
+	}
+
+	if order.ReservationID != 0 {
+		if len(order.Items) > 0 {
+			return domain.Order{}, domain.NewValidationError("Estructura de datos inválidos: una orden con reserva no puede indicar items", newFieldError("items", "excluded_with", "reservation_id"))
+		}
+
+		reservation, err := s.ReservationRepo.GetReservationByID(ctx, order.ReservationID)
 		if err != nil {
-			return domain.Order{}, errors.New("Error obteniendo el producto con ID: " + fmt.Sprint(item.ProductID))
+			return domain.Order{}, err
+		}
+
+		if !reservation.IsActiveAt(time.Now()) {
+			return domain.Order{}, domain.ErrReservationNotActive.With(domain.DetailReservation(reservation.ID))
+		}
+
+		if order.CustomerName == "" {
+			order.CustomerName = reservation.CustomerName
 		}
 
-		if product.ID == 0 {
-			return domain.Order{}, errors.New("Producto no encontrado con ID: " + fmt.Sprint(item.ProductID))
+		for _, item := range reservation.Items {
+			order.Items = append(order.Items, domain.CreateOrderItemService{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+			})
+		}
+	}
+
+	// Una línea por producto: los productos repetidos se suman
+	order.Items = domain.MergeOrderItems(order.Items)
+
+	// Tomar los locks de todos los productos antes de leer su stock
+	productIDs := make([]int, len(order.Items))
+	for i, item := range order.Items {
+		productIDs[i] = item.ProductID
+	}
+	locks, err := s.Locks.LockProducts(ctx, productIDs)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	defer locks.Release(context.WithoutCancel(ctx))
+
+	var orderData domain.Order
+	var totalAmount domain.Money
+
+	// Obtener los productos de la base de datos
+	for i, item := range order.Items {
+		product, err := s.ProductRepo.GetProductByID(ctx, item.ProductID)
+		if err != nil {
+			return domain.Order{}, err
 		}
 
-		if product.Stock < item.Quantity {
-			return domain.Order{}, errors.New("Stock insuficiente para el producto: " + product.Name)
+		// El stock de una reserva ya está retenido para esta orden
+		if order.ReservationID == 0 && product.Available() < item.Quantity {
+			return domain.Order{}, domain.ErrInsufficientStock.With(domain.DetailProductName(product.Name))
 		}
 
-		// Subtotal
-		subtotal := product.Price * float64(item.Quantity)
+		// Subtotal exacto en unidades menores
+		subtotal := product.Price.Mul(item.Quantity)
 
-		// Calcular el total
-		totalAmount += subtotal
+		// Calcular el total; todos los productos de la orden deben tener la misma moneda
+		if i == 0 {
+			totalAmount = domain.NewMoney(0, product.Price.Currency)
+		}
+		totalAmount, err = totalAmount.Add(subtotal)
+		if err != nil {
+			return domain.Order{}, fmt.Errorf("Los productos de la orden deben tener la misma moneda: %w", err)
+		}
 
 		// Asignar valores a la estructura de la orden
 		orderData.Items = append(orderData.Items, domain.OrderItem{
-			ProductID: product.ID,
-			Quantity:  item.Quantity,
-			Subtotal:  subtotal,
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   product.Price,
+			Subtotal:    subtotal,
 		})
 	}
 
+	if order.CustomerID != 0 {
+		orderData.CustomerID = &order.CustomerID
+	}
 	orderData.CustomerName = order.CustomerName
 	orderData.TotalAmount = totalAmount
+	orderData.IdempotencyKey = order.IdempotencyKey
 
 	// Crear la orden y reducir el stock dentro de una transacción
-	createdOrder, err := s.OrderRepo.CreateOrder(orderData, func(tx *sql.Tx) error {
-		for _, item := range order.Items {
-			// Adquirir un lock para el producto
-			lockKey := fmt.Sprintf("lock:product:%d", item.ProductID)
-			locked, err := s.RedisClient.AcquireLock(lockKey, 5*time.Second)
-			if err != nil || !locked {
-				return errors.New("no se pudo adquirir el lock para el producto")
+	createdOrder, err := s.OrderRepo.CreateOrder(ctx, orderData, func(tx domain.Tx, orderID int) error {
+		if err := locks.LockInTx(ctx, tx, productIDs); err != nil {
+			return err
+		}
+
+		if order.ReservationID != 0 {
+			// Confirmar la reserva verificando que no haya expirado mientras tanto
+			if _, err := s.ReservationRepo.ConsumeReservationWithTransaction(ctx, tx, order.ReservationID, orderID, time.Now()); err != nil {
+				return err
 			}
-			defer s.RedisClient.ReleaseLock(lockKey)
+		}
 
-			// Reducir el stock
-			err = s.ProductRepo.ReduceStockWithTransaction(tx, item.ProductID, item.Quantity)
+		for _, item := range order.Items {
+			// Reducir el stock, tomándolo de lo reservado si la orden viene de una reserva
+			movement := domain.StockMovement{
+				Reason:  domain.StockMovementOrder,
+				Actor:   order.Actor,
+				OrderID: &orderID,
+			}
+			var err error
+			if order.ReservationID != 0 {
+				err = s.ProductRepo.ConsumeReservedStockWithTransaction(ctx, tx, item.ProductID, item.Quantity, movement)
+			} else {
+				err = s.ProductRepo.ReduceStockWithTransaction(ctx, tx, item.ProductID, item.Quantity, movement)
+			}
 			if err != nil {
 				return err
 			}
@@ -91,6 +180,10 @@ func (s *OrderService) CreateOrder(order domain.CreateOrderService) (domain.Orde
 		return nil
 	})
 
+	// Otra solicitud con la misma clave creó la orden mientras tanto
+	if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		return s.OrderRepo.GetOrderByIdempotencyKey(ctx, order.IdempotencyKey)
+	}
 	if err != nil {
 		return domain.Order{}, err
 	}
@@ -99,6 +192,190 @@ func (s *OrderService) CreateOrder(order domain.CreateOrderService) (domain.Orde
 }
 
 // GetOrder obtiene una orden por id incluyendo sus items.
-func (s *OrderService) GetOrderByID(id int) (domain.Order, error) {
-	return s.OrderRepo.GetOrderWithItemsByID(id)
+func (s *OrderService) GetOrderByID(ctx context.Context, id int) (domain.Order, error) {
+	return s.OrderRepo.GetOrderWithItemsByID(ctx, id)
+}
+
+// TransitionOrderStatus mueve una orden al estado indicado si la transición es
+// válida. actor identifica a quien hace el cambio.
+func (s *OrderService) TransitionOrderStatus(ctx context.Context, orderID int, status domain.OrderStatus, actor string) (domain.OrderStatusTransition, error) {
+	if !status.IsValid() {
+		return domain.OrderStatusTransition{}, unknownOrderStatusError(status)
+	}
+
+	// Cancelar una orden implica devolver su stock
+	if status == domain.OrderStatusCancelled {
+		return s.CancelOrder(ctx, orderID, actor)
+	}
+
+	return s.OrderRepo.TransitionOrderStatus(ctx, orderID, status)
+}
+
+// CancelOrder cancela una orden que aún no ha sido enviada y devuelve el stock de
+// sus productos en la misma transacción. actor identifica a quien cancela.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID int, actor string) (domain.OrderStatusTransition, error) {
+	order, err := s.OrderRepo.GetOrderWithItemsByID(ctx, orderID)
+	if err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	// Tomar los locks de todos los productos de la orden antes de abrir la transacción
+	locks, err := s.Locks.LockProducts(ctx, orderProductIDs(order.Items))
+	if err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+	defer locks.Release(context.WithoutCancel(ctx))
+
+	return s.OrderRepo.CancelOrder(ctx, orderID, func(tx domain.Tx, items []domain.OrderItem) error {
+		// Los items pudieron cambiar después de tomar los locks
+		if err := locks.LockInTx(ctx, tx, orderProductIDs(items)); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			// Devolver el stock
+			err := s.ProductRepo.IncreaseStockWithTransaction(ctx, tx, item.ProductID, item.Quantity, domain.StockMovement{
+				Reason:  domain.StockMovementCancellation,
+				Actor:   actor,
+				OrderID: &orderID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AmendOrderItems reemplaza los items de una orden que aún no ha sido enviada por
+// los indicados. Los productos que ya estaban en la orden conservan su precio
+// unitario y los nuevos toman el precio actual del catálogo. En una sola
+// transacción ajusta el stock de cada producto según la diferencia de cantidades,
+// recalcula el total y registra la modificación. actor identifica a quien la hace.
+func (s *OrderService) AmendOrderItems(ctx context.Context, orderID int, amend domain.AmendOrderService) (domain.OrderAmendment, error) {
+	// Validar datos
+	if err := s.Validate.Struct(amend); err != nil {
+		return domain.OrderAmendment{}, ValidationError(err)
+	}
+
+	// Una línea por producto: los productos repetidos se suman
+	amend.Items = domain.MergeOrderItems(amend.Items)
+
+	order, err := s.OrderRepo.GetOrderWithItemsByID(ctx, orderID)
+	if err != nil {
+		return domain.OrderAmendment{}, err
+	}
+	if !order.Status.IsAmendable() {
+		return domain.OrderAmendment{}, domain.ErrOrderNotAmendable.With(domain.DetailStatus(order.Status))
+	}
+
+	// Tomar los locks de los productos actuales y de los pedidos antes de leer su stock
+	productIDs := orderProductIDs(order.Items)
+	for _, item := range amend.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	locks, err := s.Locks.LockProducts(ctx, productIDs)
+	if err != nil {
+		return domain.OrderAmendment{}, err
+	}
+	defer locks.Release(context.WithoutCancel(ctx))
+
+	// Obtener los productos pedidos; los que no estaban en la orden se agregan con
+	// su nombre y precio actuales
+	products := make(map[int]domain.Product, len(amend.Items))
+	for _, item := range amend.Items {
+		product, err := s.ProductRepo.GetProductByID(ctx, item.ProductID)
+		if err != nil {
+			return domain.OrderAmendment{}, err
+		}
+		products[product.ID] = product
+	}
+
+	return s.OrderRepo.AmendOrder(ctx, orderID, amend.Actor, func(tx domain.Tx, current domain.Order) (domain.Order, error) {
+		// Los items pudieron cambiar después de tomar los locks
+		if err := locks.LockInTx(ctx, tx, append(orderProductIDs(current.Items), productIDs...)); err != nil {
+			return domain.Order{}, err
+		}
+
+		previous := make(map[int]domain.OrderItem, len(current.Items))
+		for _, item := range current.Items {
+			previous[item.ProductID] = item
+		}
+
+		amended := current
+		amended.Items = make([]domain.OrderItem, 0, len(amend.Items))
+		amended.TotalAmount = domain.NewMoney(0, current.TotalAmount.Currency)
+		for _, item := range amend.Items {
+			line, ok := previous[item.ProductID]
+			if !ok {
+				product := products[item.ProductID]
+				line = domain.OrderItem{
+					OrderID:     orderID,
+					ProductID:   product.ID,
+					ProductName: product.Name,
+					UnitPrice:   product.Price,
+				}
+			}
+			line.Quantity = item.Quantity
+			line.Subtotal = line.UnitPrice.Mul(item.Quantity)
+
+			// Todos los productos de la orden deben tener la misma moneda
+			var err error
+			amended.TotalAmount, err = amended.TotalAmount.Add(line.Subtotal)
+			if err != nil {
+				return domain.Order{}, fmt.Errorf("Los productos de la orden deben tener la misma moneda: %w", err)
+			}
+			amended.Items = append(amended.Items, line)
+		}
+
+		// Ajustar el stock según la diferencia de cada producto
+		for _, change := range domain.DiffOrderItems(current.Items, amended.Items) {
+			movement := domain.StockMovement{
+				Reason:  domain.StockMovementAmendment,
+				Actor:   amend.Actor,
+				OrderID: &orderID,
+			}
+
+			var err error
+			if delta := change.Delta(); delta > 0 {
+				err = s.ProductRepo.ReduceStockWithTransaction(ctx, tx, change.ProductID, delta, movement)
+			} else {
+				err = s.ProductRepo.IncreaseStockWithTransaction(ctx, tx, change.ProductID, -delta, movement)
+			}
+			if err != nil {
+				return domain.Order{}, err
+			}
+		}
+
+		return amended, nil
+	})
+}
+
+// ListOrders retorna una página de órdenes que cumplen el filtro. Por defecto las
+// órdenes se ordenan de la más reciente a la más antigua.
+func (s *OrderService) ListOrders(ctx context.Context, filter domain.OrderFilter) (domain.OrderPage, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return domain.OrderPage{}, unknownOrderStatusError(filter.Status)
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "-created_at"
+	}
+	filter.Limit = domain.NormalizeLimit(filter.Limit)
+
+	return s.OrderRepo.ListOrders(ctx, filter)
+}
+
+// unknownOrderStatusError describe un estado de orden que no existe.
+func unknownOrderStatusError(status domain.OrderStatus) error {
+	return domain.NewValidationError("Estado de orden desconocido: "+string(status), newFieldError("status", "oneof", "pending paid shipped delivered cancelled"))
+}
+
+// orderProductIDs retorna los ids de producto de los items de una orden.
+func orderProductIDs(items []domain.OrderItem) []int {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+	return ids
 }
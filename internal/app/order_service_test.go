@@ -0,0 +1,375 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/container"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestCreateOrderTotalIsExactSumOfSubtotals(t *testing.T) {
+	c := newTestContainer(t)
+
+	// Precios que en float64 acumulan error de redondeo al sumarse
+	a := createProduct(t, c, "A", "0.10", 100)
+	b := createProduct(t, c, "B", "0.20", 100)
+	d := createProduct(t, c, "C", "19.99", 100)
+
+	order, err := c.OrderService.CreateOrder(context.Background(), domain.CreateOrderService{
+		CustomerName: "Ana",
+		Items: []domain.CreateOrderItemService{
+			{ProductID: a.ID, Quantity: 3},
+			{ProductID: b.ID, Quantity: 7},
+			{ProductID: d.ID, Quantity: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := domain.NewMoney(0, domain.DefaultCurrency)
+	for _, item := range order.Items {
+		if want := item.UnitPrice.Mul(item.Quantity); item.Subtotal != want {
+			t.Errorf("producto %d: subtotal %s, se esperaba %s", item.ProductID, item.Subtotal, want)
+		}
+		if sum, err = sum.Add(item.Subtotal); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if order.TotalAmount != sum {
+		t.Errorf("total %s, se esperaba la suma de los subtotales %s", order.TotalAmount, sum)
+	}
+	if want := domain.NewMoney(30+140+5997, domain.DefaultCurrency); order.TotalAmount != want {
+		t.Errorf("total %s, se esperaba %s", order.TotalAmount, want)
+	}
+}
+
+func TestCreateOrderReducesStock(t *testing.T) {
+	c := newTestContainer(t)
+	a := createProduct(t, c, "A", "10.00", 10)
+	b := createProduct(t, c, "B", "5.00", 4)
+
+	order, err := c.OrderService.CreateOrder(context.Background(), domain.CreateOrderService{
+		CustomerName: "Ana",
+		Actor:        "tester",
+		Items: []domain.CreateOrderItemService{
+			{ProductID: a.ID, Quantity: 2},
+			{ProductID: b.ID, Quantity: 4},
+			{ProductID: a.ID, Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Los productos repetidos se suman en una sola línea
+	if len(order.Items) != 2 || order.Items[0].Quantity != 3 {
+		t.Errorf("items %+v, se esperaban dos líneas con 3 unidades de A", order.Items)
+	}
+	assertStock(t, c, a.ID, 7, 0)
+	assertStock(t, c, b.ID, 0, 0)
+
+	movement := stockMovements(t, c, a.ID)[0]
+	if movement.Reason != domain.StockMovementOrder || movement.Delta != -3 || movement.Balance != 7 ||
+		movement.Actor != "tester" || movement.OrderID == nil || *movement.OrderID != order.ID {
+		t.Errorf("movimiento %+v, se esperaba la salida de 3 unidades por la orden %d", movement, order.ID)
+	}
+}
+
+func TestCreateOrderWithInsufficientStockChangesNothing(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "10.00", 10)
+	b := createProduct(t, c, "B", "5.00", 1)
+
+	_, err := c.OrderService.CreateOrder(ctx, domain.CreateOrderService{
+		CustomerName: "Ana",
+		Items: []domain.CreateOrderItemService{
+			{ProductID: a.ID, Quantity: 2},
+			{ProductID: b.ID, Quantity: 2},
+		},
+	})
+	if !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("error %v, se esperaba ErrInsufficientStock", err)
+	}
+
+	assertStock(t, c, a.ID, 10, 0)
+	assertStock(t, c, b.ID, 1, 0)
+	if movements := stockMovements(t, c, a.ID); len(movements) != 1 {
+		t.Errorf("%d movimientos de A, se esperaba solo el stock inicial", len(movements))
+	}
+
+	page, err := c.OrderService.ListOrders(ctx, domain.OrderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Orders) != 0 {
+		t.Errorf("se crearon %d órdenes, no se esperaba ninguna", len(page.Orders))
+	}
+}
+
+func TestCancelOrderRestoresStock(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "10.00", 10)
+
+	order := createOrder(t, c, domain.CreateOrderItemService{ProductID: a.ID, Quantity: 4})
+	assertStock(t, c, a.ID, 6, 0)
+
+	transition, err := c.OrderService.CancelOrder(ctx, order.ID, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.FromStatus != domain.OrderStatusPending || transition.ToStatus != domain.OrderStatusCancelled {
+		t.Errorf("transición %s → %s, se esperaba pending → cancelled", transition.FromStatus, transition.ToStatus)
+	}
+	assertStock(t, c, a.ID, 10, 0)
+
+	movement := stockMovements(t, c, a.ID)[0]
+	if movement.Reason != domain.StockMovementCancellation || movement.Delta != 4 || movement.Balance != 10 {
+		t.Errorf("movimiento %+v, se esperaba la devolución de 4 unidades", movement)
+	}
+
+	// Una orden cancelada no puede cancelarse de nuevo ni devuelve el stock dos veces
+	if _, err := c.OrderService.CancelOrder(ctx, order.ID, "tester"); !errors.Is(err, domain.ErrInvalidOrderStatusTransition) {
+		t.Errorf("segunda cancelación: error %v, se esperaba ErrInvalidOrderStatusTransition", err)
+	}
+	assertStock(t, c, a.ID, 10, 0)
+}
+
+func TestAmendOrderItemsAdjustsStockByDelta(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "2.50", 10)
+	b := createProduct(t, c, "B", "4.00", 5)
+	d := createProduct(t, c, "C", "1.00", 3)
+
+	order := createOrder(t, c,
+		domain.CreateOrderItemService{ProductID: a.ID, Quantity: 2},
+		domain.CreateOrderItemService{ProductID: b.ID, Quantity: 1},
+	)
+
+	// Un cambio de precio posterior no afecta a las líneas que ya estaban en la orden
+	price := domain.NewMoney(999, domain.DefaultCurrency)
+	if _, err := c.ProductService.UpdateProduct(ctx, a.ID, domain.UpdateProductService{Price: &price}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sube A, retira B y agrega C
+	amendment, err := c.OrderService.AmendOrderItems(ctx, order.ID, domain.AmendOrderService{
+		Actor: "tester",
+		Items: []domain.CreateOrderItemService{
+			{ProductID: a.ID, Quantity: 5},
+			{ProductID: d.ID, Quantity: 2},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStock(t, c, a.ID, 5, 0)
+	assertStock(t, c, b.ID, 5, 0)
+	assertStock(t, c, d.ID, 1, 0)
+
+	if amendment.PreviousTotal != domain.NewMoney(900, domain.DefaultCurrency) || amendment.NewTotal != domain.NewMoney(1450, domain.DefaultCurrency) {
+		t.Errorf("totales %s → %s, se esperaba 9.00 → 14.50", amendment.PreviousTotal, amendment.NewTotal)
+	}
+	want := []domain.OrderItemChange{
+		{ProductID: a.ID, ProductName: "A", PreviousQuantity: 2, NewQuantity: 5},
+		{ProductID: d.ID, ProductName: "C", PreviousQuantity: 0, NewQuantity: 2},
+		{ProductID: b.ID, ProductName: "B", PreviousQuantity: 1, NewQuantity: 0},
+	}
+	if len(amendment.Changes) != len(want) {
+		t.Fatalf("cambios %+v, se esperaban %+v", amendment.Changes, want)
+	}
+	for i := range want {
+		if amendment.Changes[i] != want[i] {
+			t.Errorf("cambio %d = %+v, se esperaba %+v", i, amendment.Changes[i], want[i])
+		}
+	}
+
+	amended, err := c.OrderService.GetOrderByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.TotalAmount != amendment.NewTotal || len(amended.Items) != 2 {
+		t.Errorf("orden %+v, se esperaban dos items con total %s", amended, amendment.NewTotal)
+	}
+
+	movement := stockMovements(t, c, b.ID)[0]
+	if movement.Reason != domain.StockMovementAmendment || movement.Delta != 1 || movement.Actor != "tester" {
+		t.Errorf("movimiento de B %+v, se esperaba la devolución de 1 unidad por la modificación", movement)
+	}
+}
+
+func TestAmendOrderItemsRepeatedDoesNotAdjustStockAgain(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "2.50", 10)
+
+	order := createOrder(t, c, domain.CreateOrderItemService{ProductID: a.ID, Quantity: 2})
+
+	// El reintento de una modificación ya aplicada pide los mismos items y no
+	// encuentra diferencias
+	amend := domain.AmendOrderService{Items: []domain.CreateOrderItemService{{ProductID: a.ID, Quantity: 5}}}
+	for range 2 {
+		if _, err := c.OrderService.AmendOrderItems(ctx, order.ID, amend); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertStock(t, c, a.ID, 5, 0)
+	if movements := stockMovements(t, c, a.ID); len(movements) != 3 {
+		t.Errorf("%d movimientos, se esperaban el stock inicial, la orden y una modificación", len(movements))
+	}
+}
+
+func TestAmendOrderItemsWithInsufficientStockChangesNothing(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "2.50", 10)
+	b := createProduct(t, c, "B", "4.00", 2)
+
+	order := createOrder(t, c,
+		domain.CreateOrderItemService{ProductID: a.ID, Quantity: 2},
+		domain.CreateOrderItemService{ProductID: b.ID, Quantity: 1},
+	)
+
+	// Bajar A devuelve stock antes de ajustar B, que no tiene suficiente; la
+	// transacción se revierte completa
+	_, err := c.OrderService.AmendOrderItems(ctx, order.ID, domain.AmendOrderService{
+		Items: []domain.CreateOrderItemService{
+			{ProductID: a.ID, Quantity: 1},
+			{ProductID: b.ID, Quantity: 3},
+		},
+	})
+	if !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("error %v, se esperaba ErrInsufficientStock", err)
+	}
+
+	assertStock(t, c, a.ID, 8, 0)
+	assertStock(t, c, b.ID, 1, 0)
+
+	unchanged, err := c.OrderService.GetOrderByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.TotalAmount != order.TotalAmount || len(unchanged.Items) != 2 {
+		t.Errorf("la orden cambió: %+v", unchanged)
+	}
+}
+
+func TestAmendOrderItemsRejectsShippedOrders(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "2.50", 10)
+	order := createOrder(t, c, domain.CreateOrderItemService{ProductID: a.ID, Quantity: 2})
+
+	for _, status := range []domain.OrderStatus{domain.OrderStatusPaid, domain.OrderStatusShipped} {
+		if _, err := c.OrderService.TransitionOrderStatus(ctx, order.ID, status, "tester"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := c.OrderService.AmendOrderItems(ctx, order.ID, domain.AmendOrderService{
+		Items: []domain.CreateOrderItemService{{ProductID: a.ID, Quantity: 1}},
+	})
+	if !errors.Is(err, domain.ErrOrderNotAmendable) {
+		t.Errorf("error %v, se esperaba ErrOrderNotAmendable", err)
+	}
+	assertStock(t, c, a.ID, 8, 0)
+}
+
+func TestListOrdersPagesWithCursor(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	product := createProduct(t, c, "A", "10.00", 20)
+
+	var ids []int
+	for i := 1; i <= 5; i++ {
+		order := createOrder(t, c, domain.CreateOrderItemService{ProductID: product.ID, Quantity: i})
+		ids = append(ids, order.ID)
+	}
+	if _, err := c.OrderService.CancelOrder(ctx, ids[1], ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Las páginas de dos órdenes por total descendente recorren todas una vez
+	var got []int
+	filter := domain.OrderFilter{Sort: "-total_amount", Limit: 2}
+	for {
+		page, err := c.OrderService.ListOrders(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Orders) > 2 {
+			t.Fatalf("página de %d órdenes, el límite es 2", len(page.Orders))
+		}
+		for _, o := range page.Orders {
+			got = append(got, o.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if want := []int{ids[4], ids[3], ids[2], ids[1], ids[0]}; !slices.Equal(got, want) {
+		t.Errorf("órdenes %v, se esperaba %v", got, want)
+	}
+
+	cancelled, err := c.OrderService.ListOrders(ctx, domain.OrderFilter{Status: domain.OrderStatusCancelled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cancelled.Orders) != 1 || cancelled.Orders[0].ID != ids[1] {
+		t.Errorf("órdenes canceladas %+v, se esperaba solo la %d", cancelled.Orders, ids[1])
+	}
+
+	// Un cursor de otro ordenamiento se rechaza
+	if _, err := c.OrderService.ListOrders(ctx, domain.OrderFilter{Sort: "id", Cursor: filter.Cursor}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("cursor de otro ordenamiento: error %v, se esperaba %v", err, domain.ErrInvalidCursor)
+	}
+}
+
+func TestOrderItemsKeepProductSnapshot(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	product := createProduct(t, c, "Teclado", "25.00", 10)
+	order := createOrder(t, c, domain.CreateOrderItemService{ProductID: product.ID, Quantity: 2})
+
+	// Cambiar el catálogo después de la compra no cambia la orden
+	name, price := "Teclado mecánico", domain.NewMoney(4000, "")
+	if _, err := c.ProductService.UpdateProduct(ctx, product.ID, domain.UpdateProductService{Name: &name, Price: &price}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.OrderService.GetOrderByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := got.Items[0]
+	if item.ProductName != "Teclado" || item.UnitPrice.Amount != 2500 || item.Subtotal.Amount != 5000 {
+		t.Errorf("item %s a %s (subtotal %s), se esperaba Teclado a 25.00 (subtotal 50.00)", item.ProductName, item.UnitPrice, item.Subtotal)
+	}
+	if got.TotalAmount.Amount != 5000 {
+		t.Errorf("total %s, se esperaba 50.00", got.TotalAmount)
+	}
+}
+
+// createOrder crea una orden anónima con los items indicados.
+func createOrder(t *testing.T, c *container.Container, items ...domain.CreateOrderItemService) domain.Order {
+	t.Helper()
+
+	order, err := c.OrderService.CreateOrder(context.Background(), domain.CreateOrderService{
+		CustomerName: "Ana",
+		Items:        items,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+)
+
+// Los servicios dependen de estas interfaces y no de las implementaciones de
+// MySQL y Redis. El paquete memory ofrece implementaciones en memoria con la misma
+// semántica transaccional para probar los servicios sin infraestructura.
+//
+// Las operaciones sobre un registro que no existe retornan el error de no
+// encontrado del dominio (domain.ErrOrderNotFound, domain.ErrProductNotFound,
+// etc.), envuelto con el id buscado, y nunca sql.ErrNoRows.
+
+// OrderRepository almacena las órdenes, sus items y su historial de estados.
+type OrderRepository interface {
+	// CreateOrder inserta la orden e invoca reduceStockFunc dentro de la misma
+	// transacción; si el callback falla no queda nada persistido.
+	CreateOrder(ctx context.Context, order domain.Order, reduceStockFunc func(tx domain.Tx, orderID int) error) (domain.Order, error)
+	GetOrderWithItemsByID(ctx context.Context, id int) (domain.Order, error)
+	// GetOrderByIdempotencyKey retorna domain.ErrOrderNotFound si ninguna orden se
+	// creó con esa clave.
+	GetOrderByIdempotencyKey(ctx context.Context, key string) (domain.Order, error)
+	TransitionOrderStatus(ctx context.Context, orderID int, status domain.OrderStatus) (domain.OrderStatusTransition, error)
+	// CancelOrder cancela la orden e invoca restoreStockFunc con sus items dentro
+	// de la misma transacción.
+	CancelOrder(ctx context.Context, orderID int, restoreStockFunc func(tx domain.Tx, items []domain.OrderItem) error) (domain.OrderStatusTransition, error)
+	// AmendOrder invoca amendFunc con la orden y sus items actuales dentro de una
+	// transacción si la orden aún no fue enviada; si no, retorna
+	// domain.ErrOrderNotAmendable. amendFunc ajusta el stock y retorna la orden con
+	// los nuevos items y total, que se guardan junto con el registro de la
+	// modificación. Si los items no cambian no se registra nada.
+	AmendOrder(ctx context.Context, orderID int, actor string, amendFunc func(tx domain.Tx, order domain.Order) (domain.Order, error)) (domain.OrderAmendment, error)
+	ListOrders(ctx context.Context, filter domain.OrderFilter) (domain.OrderPage, error)
+}
+
+// ProductRepository almacena el catálogo, el stock y su historial de movimientos.
+// Los métodos *WithTransaction operan dentro de una transacción abierta por otro
+// repositorio del mismo almacenamiento.
+type ProductRepository interface {
+	ListProducts(ctx context.Context, filter domain.ProductFilter) (domain.ProductPage, error)
+	GetProductByID(ctx context.Context, id int) (domain.Product, error)
+	// GetProductByIdempotencyKey retorna domain.ErrProductNotFound si ningún
+	// producto se creó con esa clave.
+	GetProductByIdempotencyKey(ctx context.Context, key string) (domain.Product, error)
+	// CreateProduct retorna domain.ErrDuplicateIdempotencyKey si ya existe un
+	// producto con la IdempotencyKey de product.
+	CreateProduct(ctx context.Context, product domain.Product, movement domain.StockMovement) (domain.Product, error)
+	UpdateProduct(ctx context.Context, productID int, name *string, price *domain.Money) (domain.Product, error)
+	DeleteProduct(ctx context.Context, productID int) error
+	UpdateStock(ctx context.Context, productID int, quantity int, movement domain.StockMovement) error
+	// AdjustStock retorna domain.ErrDuplicateIdempotencyKey si ya existe un
+	// movimiento con la IdempotencyKey de movement.
+	AdjustStock(ctx context.Context, productID int, delta int, movement domain.StockMovement) (domain.StockMovement, error)
+	// GetStockMovementByIdempotencyKey retorna domain.ErrStockMovementNotFound si
+	// ningún movimiento se registró con esa clave.
+	GetStockMovementByIdempotencyKey(ctx context.Context, key string) (domain.StockMovement, error)
+	ListStockMovements(ctx context.Context, productID int, limit int, cursor string) (domain.StockMovementPage, error)
+
+	ReduceStockWithTransaction(ctx context.Context, tx domain.Tx, productID int, quantity int, movement domain.StockMovement) error
+	IncreaseStockWithTransaction(ctx context.Context, tx domain.Tx, productID int, quantity int, movement domain.StockMovement) error
+	ReserveStockWithTransaction(ctx context.Context, tx domain.Tx, productID int, quantity int) error
+	ReleaseReservedStockWithTransaction(ctx context.Context, tx domain.Tx, productID int, quantity int) error
+	ConsumeReservedStockWithTransaction(ctx context.Context, tx domain.Tx, productID int, quantity int, movement domain.StockMovement) error
+	// LockProductsWithTransaction bloquea las filas de los productos hasta que
+	// termine la transacción.
+	LockProductsWithTransaction(ctx context.Context, tx domain.Tx, productIDs []int) error
+}
+
+// ReservationRepository almacena las reservas de stock.
+type ReservationRepository interface {
+	// CreateReservation inserta la reserva e invoca reserveStockFunc dentro de la
+	// misma transacción. Retorna domain.ErrDuplicateIdempotencyKey si ya existe una
+	// reserva con la IdempotencyKey de reservation.
+	CreateReservation(ctx context.Context, reservation domain.Reservation, reserveStockFunc func(tx domain.Tx) error) (domain.Reservation, error)
+	GetReservationByID(ctx context.Context, id int) (domain.Reservation, error)
+	// GetReservationByIdempotencyKey retorna domain.ErrReservationNotFound si
+	// ninguna reserva se creó con esa clave.
+	GetReservationByIdempotencyKey(ctx context.Context, key string) (domain.Reservation, error)
+	ReleaseReservation(ctx context.Context, id int, status domain.ReservationStatus, releaseStockFunc func(tx domain.Tx, items []domain.ReservationItem) error) (domain.Reservation, error)
+	ConsumeReservationWithTransaction(ctx context.Context, tx domain.Tx, id int, orderID int, now time.Time) (domain.Reservation, error)
+	ListExpiredReservationIDs(ctx context.Context, now time.Time, limit int) ([]int, error)
+}
+
+// CustomerRepository almacena los clientes y sus direcciones.
+type CustomerRepository interface {
+	// CreateCustomer retorna domain.ErrDuplicateIdempotencyKey si ya existe un
+	// cliente con la IdempotencyKey de customer.
+	CreateCustomer(ctx context.Context, customer domain.Customer) (domain.Customer, error)
+	GetCustomerByID(ctx context.Context, id int) (domain.Customer, error)
+	// GetCustomerByIdempotencyKey retorna domain.ErrCustomerNotFound si ningún
+	// cliente se creó con esa clave.
+	GetCustomerByIdempotencyKey(ctx context.Context, key string) (domain.Customer, error)
+	ListCustomers(ctx context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error)
+	UpdateCustomer(ctx context.Context, id int, data domain.UpdateCustomerService) (domain.Customer, error)
+	DeleteCustomer(ctx context.Context, id int) error
+}
+
+// Locker provee locks distribuidos con expiración y propietario.
+type Locker interface {
+	// TryLock intenta tomar el lock una sola vez; retorna domain.ErrLockNotAcquired
+	// si otro propietario lo tiene. Los reintentos los maneja acquireLock.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error)
+}
+
+// IdempotencyStore guarda el estado de las solicitudes con Idempotency-Key. Las
+// escrituras posteriores a la creación solo se aplican si el valor no cambió, de
+// modo que quien perdió una clave no pisa al nuevo dueño.
+type IdempotencyStore interface {
+	// GetIdempotencyKey retorna cache.ErrIdempotencyKeyNotFound si la clave no existe.
+	GetIdempotencyKey(ctx context.Context, key string) (*cache.IdempotencyData, error)
+	// CreateIdempotencyKey guarda data solo si la clave no existe y retorna false
+	// si ya existía.
+	CreateIdempotencyKey(ctx context.Context, key string, data cache.IdempotencyData, expiration time.Duration) (bool, error)
+	// ReplaceIdempotencyKey guarda data solo si el valor actual es old y retorna
+	// false en caso contrario.
+	ReplaceIdempotencyKey(ctx context.Context, key string, old, data cache.IdempotencyData, expiration time.Duration) (bool, error)
+	// ReleaseIdempotencyKey borra la clave solo si el valor actual es old y retorna
+	// false en caso contrario.
+	ReleaseIdempotencyKey(ctx context.Context, key string, old cache.IdempotencyData) (bool, error)
+}
@@ -1,42 +1,198 @@
 package app
 
 import (
-	"errors"
-	"fmt"
-	"time"
+	"context"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/vizardkill/order-management/internal/domain"
-	"github.com/vizardkill/order-management/internal/infrastructure/cache"
-	"github.com/vizardkill/order-management/internal/infrastructure/repo"
 )
 
+// errNonPositivePrice se retorna al crear o actualizar un producto con un precio
+// que no es mayor que cero.
+var errNonPositivePrice = domain.NewValidationError("Estructura de datos inválidos: el precio debe ser mayor que cero", newFieldError("price", "gt", "0"))
+
+// invalidCurrencyError describe una moneda que no es un código ISO 4217.
+func invalidCurrencyError(currency string) error {
+	return domain.NewValidationError("Estructura de datos inválidos: moneda inválida: "+currency, newFieldError("price.currency", "iso4217", ""))
+}
+
 type ProductService struct {
-	ProductRepo *repo.ProductRepository
-	RedisClient *cache.RedisClient
+	ProductRepo ProductRepository
+	Locks       LockStrategy
+	Validate    *validator.Validate
+}
+
+func NewProductService(productRepo ProductRepository, locks LockStrategy) *ProductService {
+	return &ProductService{ProductRepo: productRepo, Locks: locks, Validate: NewValidator()}
+}
+
+// ListProducts retorna una página de productos que cumplen el filtro. Por defecto
+// los productos se ordenan por id.
+func (s *ProductService) ListProducts(ctx context.Context, filter domain.ProductFilter) (domain.ProductPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = "id"
+	}
+	filter.Limit = domain.NormalizeLimit(filter.Limit)
+
+	return s.ProductRepo.ListProducts(ctx, filter)
+}
+
+// GetProductByID obtiene un producto por su id.
+func (s *ProductService) GetProductByID(ctx context.Context, productID int) (domain.Product, error) {
+	return s.ProductRepo.GetProductByID(ctx, productID)
+}
+
+// CreateProduct valida y registra un nuevo producto en el catálogo. Si ya existe
+// un producto creado con la misma IdempotencyKey se retorna ese producto.
+func (s *ProductService) CreateProduct(ctx context.Context, product domain.CreateProductService) (domain.Product, error) {
+	// Validar datos
+	if err := s.Validate.Struct(product); err != nil {
+		return domain.Product{}, ValidationError(err)
+	}
+
+	if !product.Price.IsPositive() {
+		return domain.Product{}, errNonPositivePrice
+	}
+
+	if product.Price.Currency == "" {
+		product.Price.Currency = domain.DefaultCurrency
+	}
+	if !domain.IsValidCurrency(product.Price.Currency) {
+		return domain.Product{}, invalidCurrencyError(product.Price.Currency)
+	}
+
+	return createIdempotent(ctx, product.IdempotencyKey, domain.ErrProductNotFound, s.ProductRepo.GetProductByIdempotencyKey, func() (domain.Product, error) {
+		return s.ProductRepo.CreateProduct(ctx, domain.Product{
+			Name:           product.Name,
+			Price:          product.Price,
+			Stock:          product.Stock,
+			IdempotencyKey: product.IdempotencyKey,
+		}, domain.StockMovement{
+			Reason: domain.StockMovementRestock,
+			Actor:  product.Actor,
+			Note:   "stock inicial",
+		})
+	})
 }
 
-func NewProductService(productRepo *repo.ProductRepository, redisClient *cache.RedisClient) *ProductService {
-	return &ProductService{ProductRepo: productRepo, RedisClient: redisClient}
+// UpdateProduct actualiza el nombre y/o el precio de un producto.
+func (s *ProductService) UpdateProduct(ctx context.Context, productID int, product domain.UpdateProductService) (domain.Product, error) {
+	// Validar datos
+	if err := s.Validate.Struct(product); err != nil {
+		return domain.Product{}, ValidationError(err)
+	}
+
+	if product.Price != nil && !product.Price.IsPositive() {
+		return domain.Product{}, errNonPositivePrice
+	}
+
+	// Sin moneda se conserva la actual del producto
+	if product.Price != nil && product.Price.Currency != "" && !domain.IsValidCurrency(product.Price.Currency) {
+		return domain.Product{}, invalidCurrencyError(product.Price.Currency)
+	}
+
+	// Adquirir un lock para el producto
+	lock, err := s.Locks.LockProducts(ctx, []int{productID})
+	if err != nil {
+		return domain.Product{}, err
+	}
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	return s.ProductRepo.UpdateProduct(ctx, productID, product.Name, product.Price)
 }
 
-func (s *ProductService) GetAllProducts() ([]domain.Product, error) {
-	return s.ProductRepo.GetAllProducts()
+// DeleteProduct elimina un producto del catálogo.
+func (s *ProductService) DeleteProduct(ctx context.Context, productID int) error {
+	// Adquirir un lock para el producto
+	lock, err := s.Locks.LockProducts(ctx, []int{productID})
+	if err != nil {
+		return err
+	}
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	return s.ProductRepo.DeleteProduct(ctx, productID)
 }
 
-func (s *ProductService) UpdateProductStockByID(productID int, newStock int) error {
+// UpdateProductStockByID reemplaza el stock de un producto. movement indica el
+// motivo (manual_adjustment o correction) y el actor del cambio.
+func (s *ProductService) UpdateProductStockByID(ctx context.Context, productID int, newStock int, movement domain.StockMovement) error {
+	if movement.Reason == "" {
+		movement.Reason = domain.StockMovementManualAdjustment
+	}
+
+	if movement.Reason != domain.StockMovementManualAdjustment && movement.Reason != domain.StockMovementCorrection {
+		return invalidStockReasonError(movement.Reason, domain.StockMovementManualAdjustment, domain.StockMovementCorrection)
+	}
+
 	// Adquirir un lock para el producto
-	lockKey := fmt.Sprintf("lock:product:%d", productID)
-	locked, err := s.RedisClient.AcquireLock(lockKey, 5*time.Second)
-	if err != nil || !locked {
-		return errors.New("no se pudo adquirir el lock para el producto")
+	lock, err := s.Locks.LockProducts(ctx, []int{productID})
+	if err != nil {
+		return err
 	}
-	defer s.RedisClient.ReleaseLock(lockKey)
+	defer lock.Release(context.WithoutCancel(ctx))
 
 	// Actualizar el stock
-	err = s.ProductRepo.UpdateStock(productID, newStock)
+	err = s.ProductRepo.UpdateStock(ctx, productID, newStock, movement)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// AdjustProductStock aplica un ajuste relativo (delta) al stock de un producto.
+// Un delta positivo se registra por defecto como reabastecimiento y uno negativo
+// como ajuste manual. Retorna el movimiento registrado con el nuevo saldo. Si ya
+// existe un movimiento registrado con la IdempotencyKey de movement, el ajuste no
+// se vuelve a aplicar y se retorna ese movimiento.
+func (s *ProductService) AdjustProductStock(ctx context.Context, productID int, delta int, movement domain.StockMovement) (domain.StockMovement, error) {
+	if delta == 0 {
+		return domain.StockMovement{}, domain.NewValidationError("Estructura de datos inválidos: el ajuste de stock no puede ser cero", newFieldError("delta", "ne", "0"))
+	}
+
+	if movement.Reason == "" {
+		movement.Reason = domain.StockMovementManualAdjustment
+		if delta > 0 {
+			movement.Reason = domain.StockMovementRestock
+		}
+	}
+
+	switch movement.Reason {
+	case domain.StockMovementRestock, domain.StockMovementManualAdjustment, domain.StockMovementCorrection:
+	default:
+		return domain.StockMovement{}, invalidStockReasonError(movement.Reason, domain.StockMovementRestock, domain.StockMovementManualAdjustment, domain.StockMovementCorrection)
+	}
+
+	// Tomar el lock del producto, como el resto de los cambios de stock
+	lock, err := s.Locks.LockProducts(ctx, []int{productID})
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	return createIdempotent(ctx, movement.IdempotencyKey, domain.ErrStockMovementNotFound, s.ProductRepo.GetStockMovementByIdempotencyKey, func() (domain.StockMovement, error) {
+		return s.ProductRepo.AdjustStock(ctx, productID, delta, movement)
+	})
+}
+
+// ListStockMovements obtiene el historial de movimientos de stock de un producto.
+func (s *ProductService) ListStockMovements(ctx context.Context, productID int, limit int, cursor string) (domain.StockMovementPage, error) {
+	// Verificar que el producto exista
+	if _, err := s.ProductRepo.GetProductByID(ctx, productID); err != nil {
+		return domain.StockMovementPage{}, err
+	}
+
+	return s.ProductRepo.ListStockMovements(ctx, productID, domain.NormalizeLimit(limit), cursor)
+}
+
+// invalidStockReasonError describe un motivo de movimiento de stock que la
+// operación no admite.
+func invalidStockReasonError(reason domain.StockMovementReason, allowed ...domain.StockMovementReason) error {
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = string(a)
+	}
+
+	return domain.NewValidationError("Estructura de datos inválidos: motivo de ajuste de stock no permitido: "+string(reason), newFieldError("reason", "oneof", strings.Join(names, " ")))
+}
@@ -0,0 +1,260 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestCreateProductValidatesCurrency(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+
+	for _, currency := range []string{"usd", "DOLLAR", "US"} {
+		_, err := c.ProductService.CreateProduct(ctx, domain.CreateProductService{
+			Name:  "A",
+			Price: domain.NewMoney(1000, currency),
+		})
+
+		domainErr, ok := domain.AsError(err)
+		if !ok || domainErr.Kind != domain.KindValidation {
+			t.Errorf("moneda %q: error %v, se esperaba un error de validación", currency, err)
+			continue
+		}
+		if len(domainErr.Fields) != 1 || domainErr.Fields[0].Field != "price.currency" {
+			t.Errorf("moneda %q: campos %+v, se esperaba price.currency", currency, domainErr.Fields)
+		}
+	}
+
+	// Sin moneda se usa la moneda por defecto
+	product, err := c.ProductService.CreateProduct(ctx, domain.CreateProductService{Name: "A", Price: domain.NewMoney(1000, "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Price.Currency != domain.DefaultCurrency {
+		t.Errorf("moneda %q, se esperaba %s", product.Price.Currency, domain.DefaultCurrency)
+	}
+}
+
+func TestUpdateProductValidatesCurrency(t *testing.T) {
+	c := newTestContainer(t)
+	product := createProduct(t, c, "A", "10.00", 1)
+
+	price := domain.NewMoney(1200, "eur")
+	_, err := c.ProductService.UpdateProduct(context.Background(), product.ID, domain.UpdateProductService{Price: &price})
+	if domainErr, ok := domain.AsError(err); !ok || domainErr.Kind != domain.KindValidation {
+		t.Errorf("error %v, se esperaba un error de validación", err)
+	}
+}
+
+func TestCreateProductWithIdempotencyKeyReturnsExistingProduct(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+
+	data := domain.CreateProductService{IdempotencyKey: "k1", Name: "A", Price: domain.NewMoney(1000, ""), Stock: 5}
+	first, err := c.ProductService.CreateProduct(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// El reintento de una solicitud interrumpida recibe el producto ya creado
+	second, err := c.ProductService.CreateProduct(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("el reintento creó el producto %d, se esperaba %d", second.ID, first.ID)
+	}
+
+	page, err := c.ProductService.ListProducts(ctx, domain.ProductFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 {
+		t.Errorf("%d productos, se esperaba 1", page.Total)
+	}
+}
+
+func TestAdjustProductStockWithIdempotencyKeyAppliesOnce(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	product := createProduct(t, c, "A", "10.00", 10)
+
+	movement := domain.StockMovement{IdempotencyKey: "k1"}
+	first, err := c.ProductService.AdjustProductStock(ctx, product.ID, 5, movement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := c.ProductService.AdjustProductStock(ctx, product.ID, 5, movement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID || second.Balance != 15 {
+		t.Errorf("el reintento retornó el movimiento %d con saldo %d, se esperaba %d con saldo 15", second.ID, second.Balance, first.ID)
+	}
+
+	assertStock(t, c, product.ID, 15, 0)
+	if movements := stockMovements(t, c, product.ID); len(movements) != 2 {
+		t.Errorf("%d movimientos, se esperaban el stock inicial y un ajuste", len(movements))
+	}
+
+	// Otra clave es otro ajuste
+	if _, err := c.ProductService.AdjustProductStock(ctx, product.ID, 5, domain.StockMovement{IdempotencyKey: "k2"}); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, c, product.ID, 20, 0)
+}
+
+// recordingLocks registra los productos que se bloquean con la estrategia
+// envuelta.
+type recordingLocks struct {
+	app.LockStrategy
+	locked [][]int
+}
+
+func (l *recordingLocks) LockProducts(ctx context.Context, productIDs []int) (app.ProductLocks, error) {
+	l.locked = append(l.locked, slices.Clone(productIDs))
+	return l.LockStrategy.LockProducts(ctx, productIDs)
+}
+
+func TestAdjustProductStockLocksProduct(t *testing.T) {
+	c := newTestContainer(t)
+	product := createProduct(t, c, "A", "10.00", 10)
+
+	locks := &recordingLocks{LockStrategy: c.Locks}
+	service := app.NewProductService(c.ProductRepo, locks)
+	if _, err := service.AdjustProductStock(context.Background(), product.ID, -3, domain.StockMovement{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(locks.locked) != 1 || !slices.Equal(locks.locked[0], []int{product.ID}) {
+		t.Errorf("locks tomados %v, se esperaba el del producto %d", locks.locked, product.ID)
+	}
+	assertStock(t, c, product.ID, 7, 0)
+}
+
+func TestDeleteProductKeepsStockMovements(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+
+	stocked := createProduct(t, c, "A", "10.00", 5)
+	if err := c.ProductService.DeleteProduct(ctx, stocked.ID); !errors.Is(err, domain.ErrProductHasStockMovements) {
+		t.Fatalf("eliminar un producto con movimientos: error %v, se esperaba %v", err, domain.ErrProductHasStockMovements)
+	}
+	if movements := stockMovements(t, c, stocked.ID); len(movements) != 1 {
+		t.Errorf("%d movimientos, se esperaba conservar el stock inicial", len(movements))
+	}
+
+	// Un producto sin movimientos sí puede eliminarse
+	empty := createProduct(t, c, "B", "10.00", 0)
+	if err := c.ProductService.DeleteProduct(ctx, empty.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ProductService.GetProductByID(ctx, empty.ID); !errors.Is(err, domain.ErrProductNotFound) {
+		t.Errorf("el producto sigue existiendo: error %v", err)
+	}
+}
+
+func TestListProductsSortsSearchesAndPages(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+
+	mouse := createProduct(t, c, "Mouse", "15.00", 0)
+	keyboard := createProduct(t, c, "Teclado mecánico", "80.00", 3)
+	monitor := createProduct(t, c, "Monitor", "200.00", 1)
+	pad := createProduct(t, c, "Mouse pad", "5.00", 8)
+
+	// Las páginas de dos productos por precio descendente recorren todos una vez
+	var got []int
+	filter := domain.ProductFilter{Sort: "-price", Limit: 2}
+	for {
+		page, err := c.ProductService.ListProducts(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 4 || len(page.Products) > 2 {
+			t.Fatalf("página de %d productos con total %d, se esperaban a lo sumo 2 de 4", len(page.Products), page.Total)
+		}
+		for _, p := range page.Products {
+			got = append(got, p.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if want := []int{monitor.ID, keyboard.ID, mouse.ID, pad.ID}; !slices.Equal(got, want) {
+		t.Errorf("productos %v, se esperaba %v", got, want)
+	}
+
+	// La búsqueda no distingue mayúsculas y se combina con el filtro de stock
+	inStock := true
+	page, err := c.ProductService.ListProducts(ctx, domain.ProductFilter{Search: "MOUSE", InStock: &inStock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Products) != 1 || page.Products[0].ID != pad.ID {
+		t.Errorf("búsqueda: %+v, se esperaba solo el producto %d", page.Products, pad.ID)
+	}
+
+	// Un límite mayor al máximo se recorta
+	page, err = c.ProductService.ListProducts(ctx, domain.ProductFilter{Limit: domain.MaxPageLimit + 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Products) != 4 || page.NextCursor != "" {
+		t.Errorf("%d productos y cursor %q, se esperaban los 4 en una página", len(page.Products), page.NextCursor)
+	}
+}
+
+func TestAdjustProductStockRejectsStockBelowZeroOrReserved(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	product := createProduct(t, c, "A", "10.00", 10)
+
+	if _, err := c.ReservationService.CreateReservation(ctx, domain.CreateReservationService{
+		CustomerName: "Ana",
+		Items:        []domain.CreateReservationItemService{{ProductID: product.ID, Quantity: 4}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		delta int
+	}{
+		{"por debajo de cero", -11},
+		{"por debajo de lo reservado", -7},
+	}
+
+	for _, tt := range tests {
+		_, err := c.ProductService.AdjustProductStock(ctx, product.ID, tt.delta, domain.StockMovement{})
+		if !errors.Is(err, domain.ErrInsufficientStock) {
+			t.Errorf("%s: error %v, se esperaba %v", tt.name, err, domain.ErrInsufficientStock)
+			continue
+		}
+		if detail, ok := domain.DetailOf(err); !ok || detail.Code != "detail_stock_adjustment" || !slices.Equal(detail.Args, []any{tt.delta, product.ID}) {
+			t.Errorf("%s: detalle %+v, se esperaba detail_stock_adjustment con el ajuste y el producto", tt.name, detail)
+		}
+	}
+
+	// Los ajustes rechazados no cambian el stock ni registran movimientos
+	assertStock(t, c, product.ID, 10, 4)
+	if movements := stockMovements(t, c, product.ID); len(movements) != 1 {
+		t.Errorf("%d movimientos, se esperaba solo el stock inicial", len(movements))
+	}
+
+	// Bajar hasta exactamente lo reservado está permitido
+	movement, err := c.ProductService.AdjustProductStock(ctx, product.ID, -6, domain.StockMovement{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movement.Balance != 4 {
+		t.Errorf("saldo %d, se esperaba 4", movement.Balance)
+	}
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// expiredReservationsBatch es la cantidad máxima de reservas vencidas que se
+// liberan en cada pasada del barrido.
+const expiredReservationsBatch = 100
+
+type ReservationService struct {
+	ReservationRepo ReservationRepository
+	ProductRepo     ProductRepository
+	Locks           LockStrategy
+	Validate        *validator.Validate
+}
+
+func NewReservationService(reservationRepo ReservationRepository, productRepo ProductRepository, locks LockStrategy) *ReservationService {
+	return &ReservationService{
+		ReservationRepo: reservationRepo,
+		ProductRepo:     productRepo,
+		Locks:           locks,
+		Validate:        NewValidator(),
+	}
+}
+
+// CreateReservation retiene el stock de los productos indicados durante el TTL
+// de la reserva. El stock disponible se reduce pero el stock físico no cambia
+// hasta que la reserva se confirma como orden. Si ya existe una reserva creada con
+// la misma IdempotencyKey se retorna esa reserva.
+func (s *ReservationService) CreateReservation(ctx context.Context, data domain.CreateReservationService) (domain.Reservation, error) {
+	// Validar datos
+	if err := s.Validate.Struct(data); err != nil {
+		return domain.Reservation{}, ValidationError(err)
+	}
+
+	ttl := data.TTL
+	if ttl == 0 {
+		ttl = domain.DefaultReservationTTL
+	}
+	if ttl > domain.MaxReservationTTL {
+		maxMinutes := fmt.Sprint(int(domain.MaxReservationTTL.Minutes()))
+		return domain.Reservation{}, domain.NewValidationError(
+			fmt.Sprintf("Estructura de datos inválidos: la reserva no puede durar más de %s", domain.MaxReservationTTL),
+			newFieldError("ttl_minutes", "max", maxMinutes),
+		)
+	}
+
+	return createIdempotent(ctx, data.IdempotencyKey, domain.ErrReservationNotFound, s.ReservationRepo.GetReservationByIdempotencyKey, func() (domain.Reservation, error) {
+		return s.createReservation(ctx, data, ttl)
+	})
+}
+
+// createReservation inserta la reserva y retiene su stock en una transacción.
+func (s *ReservationService) createReservation(ctx context.Context, data domain.CreateReservationService, ttl time.Duration) (domain.Reservation, error) {
+	reservation := domain.Reservation{
+		CustomerName:   data.CustomerName,
+		ExpiresAt:      time.Now().UTC().Add(ttl).Truncate(time.Second),
+		IdempotencyKey: data.IdempotencyKey,
+	}
+	// Una línea por producto: los productos repetidos se suman
+	for _, item := range domain.MergeReservationItems(data.Items) {
+		reservation.Items = append(reservation.Items, domain.ReservationItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	// Tomar los locks de todos los productos antes de retener su stock
+	productIDs := make([]int, len(reservation.Items))
+	for i, item := range reservation.Items {
+		productIDs[i] = item.ProductID
+	}
+	locks, err := s.Locks.LockProducts(ctx, productIDs)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer locks.Release(context.WithoutCancel(ctx))
+
+	return s.ReservationRepo.CreateReservation(ctx, reservation, func(tx domain.Tx) error {
+		if err := locks.LockInTx(ctx, tx, productIDs); err != nil {
+			return err
+		}
+
+		for _, item := range reservation.Items {
+			// Retener el stock
+			if err := s.ProductRepo.ReserveStockWithTransaction(ctx, tx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetReservationByID obtiene una reserva por su id incluyendo sus items.
+func (s *ReservationService) GetReservationByID(ctx context.Context, id int) (domain.Reservation, error) {
+	return s.ReservationRepo.GetReservationByID(ctx, id)
+}
+
+// ReleaseReservation libera una reserva activa y devuelve su stock al disponible.
+func (s *ReservationService) ReleaseReservation(ctx context.Context, id int) (domain.Reservation, error) {
+	return s.closeReservation(ctx, id, domain.ReservationStatusReleased)
+}
+
+// ExpireReservations libera las reservas activas cuyo vencimiento ya pasó y
+// retorna cuántas se liberaron.
+func (s *ReservationService) ExpireReservations(ctx context.Context) (int, error) {
+	ids, err := s.ReservationRepo.ListExpiredReservationIDs(ctx, time.Now().UTC(), expiredReservationsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		if _, err := s.closeReservation(ctx, id, domain.ReservationStatusExpired); err != nil {
+			// La reserva pudo confirmarse o liberarse después de listarla
+			if errors.Is(err, domain.ErrReservationNotActive) {
+				continue
+			}
+			log.Printf("Error liberando la reserva expirada %d: %v", id, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// RunExpirationSweeper libera periódicamente las reservas expiradas. Bloquea
+// hasta que ctx se cancela, por lo que debe ejecutarse en su propia goroutine.
+func (s *ReservationService) RunExpirationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := s.ExpireReservations(ctx)
+		if err != nil {
+			log.Printf("Error buscando reservas expiradas: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Se liberaron %d reservas expiradas", expired)
+		}
+	}
+}
+
+// closeReservation cierra una reserva activa con el estado indicado devolviendo
+// el stock retenido bajo los locks de sus productos.
+func (s *ReservationService) closeReservation(ctx context.Context, id int, status domain.ReservationStatus) (domain.Reservation, error) {
+	reservation, err := s.ReservationRepo.GetReservationByID(ctx, id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	// Tomar los locks de todos los productos de la reserva antes de abrir la
+	// transacción; los items de una reserva no cambian
+	productIDs := make([]int, len(reservation.Items))
+	for i, item := range reservation.Items {
+		productIDs[i] = item.ProductID
+	}
+	locks, err := s.Locks.LockProducts(ctx, productIDs)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer locks.Release(context.WithoutCancel(ctx))
+
+	return s.ReservationRepo.ReleaseReservation(ctx, id, status, func(tx domain.Tx, items []domain.ReservationItem) error {
+		if err := locks.LockInTx(ctx, tx, productIDs); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			// Devolver el stock retenido
+			if err := s.ProductRepo.ReleaseReservedStockWithTransaction(ctx, tx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
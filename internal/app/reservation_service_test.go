@@ -0,0 +1,143 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestReservationHoldsStockUntilConvertedToOrder(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "10.00", 10)
+	b := createProduct(t, c, "B", "5.00", 3)
+
+	reservation, err := c.ReservationService.CreateReservation(ctx, domain.CreateReservationService{
+		CustomerName: "Ana",
+		Items: []domain.CreateReservationItemService{
+			{ProductID: a.ID, Quantity: 4},
+			{ProductID: b.ID, Quantity: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// La reserva retiene el stock sin cambiar el stock físico
+	assertStock(t, c, a.ID, 10, 4)
+	assertStock(t, c, b.ID, 3, 3)
+
+	// Otra orden no puede tomar el stock retenido
+	_, err = c.OrderService.CreateOrder(ctx, domain.CreateOrderService{
+		CustomerName: "Beto",
+		Items:        []domain.CreateOrderItemService{{ProductID: b.ID, Quantity: 1}},
+	})
+	if !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("orden sobre stock retenido: error %v, se esperaba ErrInsufficientStock", err)
+	}
+
+	order, err := c.OrderService.CreateOrder(ctx, domain.CreateOrderService{ReservationID: reservation.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.CustomerName != "Ana" || len(order.Items) != 2 {
+		t.Errorf("orden %+v, se esperaban los items y el cliente de la reserva", order)
+	}
+	if want := domain.NewMoney(5500, domain.DefaultCurrency); order.TotalAmount != want {
+		t.Errorf("total %s, se esperaba %s", order.TotalAmount, want)
+	}
+
+	// La orden consume lo retenido
+	assertStock(t, c, a.ID, 6, 0)
+	assertStock(t, c, b.ID, 0, 0)
+
+	confirmed, err := c.ReservationService.GetReservationByID(ctx, reservation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Status != domain.ReservationStatusConfirmed || confirmed.OrderID == nil || *confirmed.OrderID != order.ID {
+		t.Errorf("reserva %+v, se esperaba confirmada con la orden %d", confirmed, order.ID)
+	}
+
+	// Una reserva confirmada no puede usarse otra vez
+	_, err = c.OrderService.CreateOrder(ctx, domain.CreateOrderService{ReservationID: reservation.ID})
+	if !errors.Is(err, domain.ErrReservationNotActive) {
+		t.Errorf("segunda orden: error %v, se esperaba ErrReservationNotActive", err)
+	}
+}
+
+func TestExpireReservationsReleasesHeldStock(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "10.00", 10)
+
+	// El servicio no crea reservas vencidas, así que se crea con el repositorio
+	expired, err := c.ReservationRepo.CreateReservation(ctx, domain.Reservation{
+		CustomerName: "Ana",
+		ExpiresAt:    time.Now().UTC().Add(-time.Minute).Truncate(time.Second),
+		Items:        []domain.ReservationItem{{ProductID: a.ID, Quantity: 4}},
+	}, func(tx domain.Tx) error {
+		return c.ProductRepo.ReserveStockWithTransaction(ctx, tx, a.ID, 4)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, c, a.ID, 10, 4)
+
+	// Una reserva vencida no puede convertirse en orden aunque el barrido no la haya liberado
+	_, err = c.OrderService.CreateOrder(ctx, domain.CreateOrderService{ReservationID: expired.ID})
+	if !errors.Is(err, domain.ErrReservationNotActive) {
+		t.Errorf("orden con reserva vencida: error %v, se esperaba ErrReservationNotActive", err)
+	}
+
+	count, err := c.ReservationService.ExpireReservations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("se liberaron %d reservas, se esperaba 1", count)
+	}
+	assertStock(t, c, a.ID, 10, 0)
+
+	released, err := c.ReservationService.GetReservationByID(ctx, expired.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if released.Status != domain.ReservationStatusExpired {
+		t.Errorf("estado %s, se esperaba expired", released.Status)
+	}
+
+	// El barrido siguiente no la libera dos veces
+	if count, err := c.ReservationService.ExpireReservations(ctx); err != nil || count != 0 {
+		t.Errorf("segundo barrido: %d liberadas, error %v", count, err)
+	}
+	assertStock(t, c, a.ID, 10, 0)
+}
+
+func TestCreateReservationWithIdempotencyKeyHoldsStockOnce(t *testing.T) {
+	c := newTestContainer(t)
+	ctx := context.Background()
+	a := createProduct(t, c, "A", "10.00", 10)
+
+	data := domain.CreateReservationService{
+		IdempotencyKey: "k1",
+		CustomerName:   "Ana",
+		Items:          []domain.CreateReservationItemService{{ProductID: a.ID, Quantity: 4}},
+	}
+	first, err := c.ReservationService.CreateReservation(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := c.ReservationService.CreateReservation(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("el reintento creó la reserva %d, se esperaba %d", second.ID, first.ID)
+	}
+	assertStock(t, c, a.ID, 10, 4)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/vizardkill/order-management/internal/domain"
+	"github.com/vizardkill/order-management/internal/i18n"
+)
+
+// NewValidator crea el validador de los servicios y los manejadores. Los errores
+// nombran los campos por su nombre JSON cuando lo tienen.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return validate
+}
+
+// ValidationError convierte los errores de validator en un error de validación del
+// dominio con el detalle de cada campo. Cualquier otro error se retorna sin cambios.
+func ValidationError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]domain.FieldError, len(validationErrs))
+	for i, fieldErr := range validationErrs {
+		rule := i18n.Rule{Tag: fieldErr.Tag(), Param: fieldParam(fieldErr), Unit: fieldUnit(fieldErr)}
+		fields[i] = domain.FieldError{
+			Field:   fieldPath(fieldErr.Namespace()),
+			Rule:    rule.Tag,
+			Param:   rule.Param,
+			Message: i18n.FieldMessage(i18n.Default, rule),
+			Unit:    rule.Unit,
+		}
+	}
+	return domain.NewValidationError("Estructura de datos inválidos", fields...)
+}
+
+// fieldPath quita el nombre de la estructura raíz: CreateOrderRequest.items[0].quantity
+// se reporta como items[0].quantity.
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// fieldParam retorna el parámetro de la regla. Las reglas que nombran otros campos
+// los reciben por su nombre en Go, por lo que se traducen a snake_case.
+func fieldParam(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required_without", "required_without_all", "excluded_with":
+		names := strings.Fields(fieldErr.Param())
+		for i, name := range names {
+			names[i] = snakeCase(name)
+		}
+		return strings.Join(names, " ")
+	default:
+		return fieldErr.Param()
+	}
+}
+
+// snakeCase convierte un nombre de campo de Go, como ReservationID, en
+// reservation_id.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		// Una mayúscula inicia palabra salvo dentro de una sigla como ID
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// fieldUnit retorna qué miden min, max y len en el campo: la longitud de un texto,
+// la de una lista o, si es "", su valor.
+func fieldUnit(fieldErr validator.FieldError) string {
+	switch fieldErr.Kind() {
+	case reflect.String:
+		return i18n.UnitCharacters
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return i18n.UnitItems
+	default:
+		return ""
+	}
+}
+
+// newFieldError crea el detalle de un campo que incumplió la regla tag con param,
+// descrito en el idioma por defecto.
+func newFieldError(field, tag, param string) domain.FieldError {
+	return domain.FieldError{
+		Field:   field,
+		Rule:    tag,
+		Param:   param,
+		Message: i18n.FieldMessage(i18n.Default, i18n.Rule{Tag: tag, Param: param}),
+	}
+}
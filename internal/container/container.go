@@ -0,0 +1,127 @@
+// Package container arma la aplicación: crea los repositorios, servicios y
+// manejadores y los conecta explícitamente, sin estado global.
+package container
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/vizardkill/order-management/api/handlers"
+	"github.com/vizardkill/order-management/api/routes"
+	"github.com/vizardkill/order-management/config"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+	"github.com/vizardkill/order-management/internal/infrastructure/memory"
+	"github.com/vizardkill/order-management/internal/infrastructure/repo"
+)
+
+// Container agrupa las dependencias de la aplicación.
+type Container struct {
+	Config *config.Config
+
+	// Infraestructura. DB y Redis son nil en el contenedor en memoria.
+	DB          *sql.DB
+	Redis       *cache.RedisClient
+	Locker      app.Locker
+	Idempotency app.IdempotencyStore
+	// RedisHealth es nil en el contenedor en memoria, que no se degrada.
+	RedisHealth *app.HealthMonitor
+	Locks       app.LockStrategy
+
+	// Repositorios
+	ProductRepo     app.ProductRepository
+	OrderRepo       app.OrderRepository
+	ReservationRepo app.ReservationRepository
+	CustomerRepo    app.CustomerRepository
+
+	// Servicios
+	ProductService     *app.ProductService
+	OrderService       *app.OrderService
+	ReservationService *app.ReservationService
+	CustomerService    *app.CustomerService
+
+	// Manejadores
+	ProductHandler     *handlers.ProductHandler
+	OrderHandler       *handlers.OrderHandler
+	ReservationHandler *handlers.ReservationHandler
+	CustomerHandler    *handlers.CustomerHandler
+}
+
+// New crea el contenedor sobre MySQL y Redis.
+func New(cfg *config.Config, db *sql.DB, redis *cache.RedisClient) (*Container, error) {
+	// Mientras Redis no responde, las claves de idempotencia se guardan en MySQL
+	redisHealth := app.NewHealthMonitor("redis", redis.Ping)
+	c := &Container{
+		Config:          cfg,
+		DB:              db,
+		Redis:           redis,
+		Locker:          redis,
+		Idempotency:     app.NewFallbackIdempotencyStore(redis, repo.NewIdempotencyStore(db), redisHealth),
+		RedisHealth:     redisHealth,
+		ProductRepo:     repo.NewProductRepository(db),
+		OrderRepo:       repo.NewOrderRepository(db),
+		ReservationRepo: repo.NewReservationRepository(db),
+		CustomerRepo:    repo.NewCustomerRepository(db),
+	}
+	if err := c.wire(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewInMemory crea el contenedor sobre las implementaciones en memoria. Cada
+// llamada tiene sus propios datos, por lo que varias instancias pueden convivir
+// en el mismo proceso.
+func NewInMemory(cfg *config.Config) (*Container, error) {
+	store := memory.NewStore()
+	c := &Container{
+		Config:          cfg,
+		Locker:          memory.NewLocker(),
+		Idempotency:     memory.NewIdempotencyStore(),
+		ProductRepo:     memory.NewProductRepository(store),
+		OrderRepo:       memory.NewOrderRepository(store),
+		ReservationRepo: memory.NewReservationRepository(store),
+		CustomerRepo:    memory.NewCustomerRepository(store),
+	}
+	if err := c.wire(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// wire crea la estrategia de lock, los servicios y los manejadores a partir de
+// los repositorios.
+func (c *Container) wire() error {
+	locks, err := app.NewLockStrategy(c.Config.LockStrategy, c.Locker, c.ProductRepo, c.RedisHealth)
+	if err != nil {
+		return err
+	}
+	c.Locks = locks
+
+	c.ProductService = app.NewProductService(c.ProductRepo, c.Locks)
+	c.OrderService = app.NewOrderService(c.OrderRepo, c.ProductRepo, c.ReservationRepo, c.CustomerRepo, c.Locks)
+	c.ReservationService = app.NewReservationService(c.ReservationRepo, c.ProductRepo, c.Locks)
+	c.CustomerService = app.NewCustomerService(c.CustomerRepo, c.OrderRepo)
+
+	c.ProductHandler = handlers.NewProductHandler(c.ProductService)
+	c.OrderHandler = handlers.NewOrderHandler(c.OrderService)
+	c.ReservationHandler = handlers.NewReservationHandler(c.ReservationService, c.OrderService)
+	c.CustomerHandler = handlers.NewCustomerHandler(c.CustomerService)
+	return nil
+}
+
+// Server construye el http.Handler de la API con los manejadores del contenedor.
+func (c *Container) Server() http.Handler {
+	return routes.NewServer(routes.Dependencies{
+		ProductHandler:     c.ProductHandler,
+		OrderHandler:       c.OrderHandler,
+		ReservationHandler: c.ReservationHandler,
+		CustomerHandler:    c.CustomerHandler,
+		Idempotency:        c.Idempotency,
+	})
+}
+
+// AdminServer construye el http.Handler de administración con las métricas.
+func (c *Container) AdminServer() http.Handler {
+	return routes.NewAdminServer()
+}
@@ -0,0 +1,135 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vizardkill/order-management/config"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+	"github.com/vizardkill/order-management/internal/infrastructure/memory"
+)
+
+var errRedisDown = errors.New("redis no responde")
+
+// downLocker y downIdempotencyStore simulan un Redis caído: todas las
+// operaciones fallan.
+type downLocker struct{}
+
+func (downLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	return nil, errRedisDown
+}
+
+type downIdempotencyStore struct{}
+
+func (downIdempotencyStore) GetIdempotencyKey(ctx context.Context, key string) (*cache.IdempotencyData, error) {
+	return nil, errRedisDown
+}
+
+func (downIdempotencyStore) CreateIdempotencyKey(ctx context.Context, key string, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	return false, errRedisDown
+}
+
+func (downIdempotencyStore) ReplaceIdempotencyKey(ctx context.Context, key string, old, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	return false, errRedisDown
+}
+
+func (downIdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key string, old cache.IdempotencyData) (bool, error) {
+	return false, errRedisDown
+}
+
+// newRedisDownContainer arma la aplicación como container.New, con Redis caído y
+// el almacenamiento en memoria en lugar de MySQL.
+func newRedisDownContainer(t *testing.T) *Container {
+	t.Helper()
+
+	store := memory.NewStore()
+	health := app.NewHealthMonitor("redis-prueba", func(ctx context.Context) error { return errRedisDown })
+	c := &Container{
+		Config:          &config.Config{},
+		Locker:          downLocker{},
+		Idempotency:     app.NewFallbackIdempotencyStore(downIdempotencyStore{}, memory.NewIdempotencyStore(), health),
+		RedisHealth:     health,
+		ProductRepo:     memory.NewProductRepository(store),
+		OrderRepo:       memory.NewOrderRepository(store),
+		ReservationRepo: memory.NewReservationRepository(store),
+		CustomerRepo:    memory.NewCustomerRepository(store),
+	}
+	if err := c.wire(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestWritesSucceedWhileRedisIsDown(t *testing.T) {
+	c := newRedisDownContainer(t)
+	server := c.Server()
+
+	product, err := c.ProductService.CreateProduct(context.Background(), domain.CreateProductService{Name: "A", Price: domain.NewMoney(1000, ""), Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := fmt.Sprintf(`{"customer_name":"Ana","items":[{"product_id":%d,"quantity":2}]}`, product.ID)
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "orden-sin-redis")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post()
+	if first.Code != http.StatusCreated {
+		t.Fatalf("con Redis caído la orden respondió %d: %s", first.Code, first.Body)
+	}
+	if c.RedisHealth.Healthy() {
+		t.Error("el fallo de Redis no lo marcó como no disponible")
+	}
+
+	// El reintento se repite desde el almacén de respaldo sin descontar de nuevo
+	retry := post()
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("reintento: %d %s, se esperaba la respuesta original", retry.Code, retry.Body)
+	}
+
+	got, err := c.ProductService.GetProductByID(context.Background(), product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stock != 3 {
+		t.Errorf("stock %d, se esperaba 3", got.Stock)
+	}
+}
+
+func TestMetricsAreServedOnlyByAdminServer(t *testing.T) {
+	c, err := NewInMemory(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		status  int
+	}{
+		{"API", c.Server(), http.StatusNotFound},
+		{"administración", c.AdminServer(), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: GET /debug/vars respondió %d, se esperaba %d", tt.name, rec.Code, tt.status)
+		}
+	}
+}
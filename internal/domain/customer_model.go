@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+var (
+	// ErrCustomerEmailTaken se retorna cuando ya existe un cliente con el mismo email.
+	ErrCustomerEmailTaken = NewConflictError("customer_email_taken", "ya existe un cliente con ese email")
+	// ErrCustomerHasOrders se retorna cuando se intenta eliminar un cliente que
+	// tiene órdenes asociadas.
+	ErrCustomerHasOrders = NewConflictError("customer_has_orders", "el cliente tiene órdenes asociadas y no puede eliminarse")
+	// ErrCustomerNotFound se retorna cuando el cliente no existe.
+	ErrCustomerNotFound = NewNotFoundError("customer_not_found", "cliente no encontrado")
+)
+
+type Customer struct {
+	ID        int
+	Name      string
+	Email     string
+	Phone     string
+	Addresses []Address
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// IdempotencyKey es la clave de la solicitud que creó el cliente; vacía si se
+	// creó sin una. No se expone en las respuestas.
+	IdempotencyKey string `json:"-"`
+}
+
+// Address es una dirección de un cliente. Country es el código ISO 3166-1 alfa-2.
+type Address struct {
+	ID         int
+	CustomerID int
+	Label      string `validate:"max=50"`
+	Line1      string `validate:"required,max=255"`
+	Line2      string `validate:"max=255"`
+	City       string `validate:"required,max=100"`
+	State      string `validate:"max=100"`
+	PostalCode string `validate:"max=20"`
+	Country    string `validate:"required,len=2"`
+}
+
+// CreateCustomerService contiene los datos de un cliente nuevo. Si IdempotencyKey
+// no viene vacía y ya existe un cliente con esa clave, se retorna ese cliente.
+type CreateCustomerService struct {
+	IdempotencyKey string
+	Name           string    `validate:"required,max=255"`
+	Email          string    `validate:"required,email,max=255"`
+	Phone          string    `validate:"omitempty,max=30"`
+	Addresses      []Address `validate:"dive"`
+}
+
+// UpdateCustomerService contiene los campos modificables de un cliente; los campos
+// nil se dejan sin cambios. Si Addresses no es nil reemplaza todas las direcciones.
+type UpdateCustomerService struct {
+	Name      *string    `validate:"omitempty,min=1,max=255"`
+	Email     *string    `validate:"omitempty,email,max=255"`
+	Phone     *string    `validate:"omitempty,max=30"`
+	Addresses *[]Address `validate:"omitempty,dive"`
+}
+
+// CustomerFilter agrupa los criterios para listar clientes.
+type CustomerFilter struct {
+	// Search filtra los clientes cuyo nombre o email contiene el texto indicado.
+	Search string
+	Limit  int
+	Cursor string
+}
+
+// CustomerPage es una página de clientes ordenados por id.
+type CustomerPage struct {
+	Customers  []Customer
+	NextCursor string
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind clasifica los errores del dominio según lo que el cliente puede hacer
+// al recibirlos; la API traduce cada clase a un código de estado HTTP.
+type ErrorKind int
+
+const (
+	// KindNotFound indica que el recurso solicitado no existe.
+	KindNotFound ErrorKind = iota + 1
+	// KindConflict indica que el estado actual del recurso impide la operación.
+	KindConflict
+	// KindValidation indica que los datos enviados no son válidos.
+	KindValidation
+	// KindInsufficientStock indica que no hay stock disponible suficiente.
+	KindInsufficientStock
+)
+
+// CodeValidationFailed es el código de los errores de validación de datos.
+const CodeValidationFailed = "validation_failed"
+
+// Error es un error del dominio con un código estable que los clientes pueden usar
+// para distinguirlo sin depender del mensaje. Los errores centinela del dominio son
+// *Error; el contexto en que ocurren (el id buscado, los estados de una transición)
+// se agrega con With para que la API pueda describirlo en el idioma del cliente.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	// Fields detalla los campos inválidos de un error de validación.
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldError describe un campo que no pasó la validación. Rule y Param son la regla
+// incumplida y su parámetro, por ejemplo max y 255.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+	// Unit indica si la regla mide caracteres o elementos (ver i18n.Rule); permite
+	// describir el error en el idioma del cliente.
+	Unit string `json:"-"`
+}
+
+// NewNotFoundError crea un error para un recurso que no existe.
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// NewConflictError crea un error para una operación que el estado actual impide.
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+// NewValidationError crea un error de validación con el detalle de los campos
+// inválidos.
+func NewValidationError(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: CodeValidationFailed, Message: message, Fields: fields}
+}
+
+// AsError retorna el error del dominio envuelto en err, si lo hay.
+func AsError(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
+
+// Detail es el contexto de un error del dominio. Code identifica el formato para que
+// la API lo traduzca, Format es el formato en español que usa Error y Args son los
+// valores, que no dependen del idioma.
+type Detail struct {
+	Code   string
+	Format string
+	Args   []any
+}
+
+// detailError es un error del dominio junto con su contexto.
+type detailError struct {
+	err    *Error
+	detail Detail
+}
+
+func (e *detailError) Error() string {
+	return e.err.Message + fmt.Sprintf(e.detail.Format, e.detail.Args...)
+}
+
+func (e *detailError) Unwrap() error {
+	return e.err
+}
+
+// With retorna e con el contexto detail. El resultado sigue siendo e para
+// errors.Is y AsError.
+func (e *Error) With(detail Detail) error {
+	return &detailError{err: e, detail: detail}
+}
+
+// DetailOf retorna el contexto agregado con With al error del dominio envuelto en
+// err, si lo hay.
+func DetailOf(err error) (Detail, bool) {
+	var detailErr *detailError
+	if errors.As(err, &detailErr) {
+		return detailErr.detail, true
+	}
+	return Detail{}, false
+}
+
+// DetailID identifica el registro con el id indicado.
+func DetailID(id int) Detail {
+	return Detail{Code: "detail_id", Format: " con ID %d", Args: []any{id}}
+}
+
+// DetailStatus indica el estado actual del registro.
+func DetailStatus(status any) Detail {
+	return Detail{Code: "detail_status", Format: ": estado %s", Args: []any{status}}
+}
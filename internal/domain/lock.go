@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLockNotAcquired se retorna cuando otro propietario tiene el lock.
+var ErrLockNotAcquired = NewConflictError("resource_locked", "el lock está tomado por otro proceso")
+
+// DetailLocksChanged indica que los productos de la operación cambiaron después de
+// tomar sus locks.
+func DetailLocksChanged() Detail {
+	return Detail{Code: "detail_locks_changed", Format: ": los productos cambiaron después de tomar los locks, intente nuevamente"}
+}
+
+// ErrLockNotHeld se retorna al extender o liberar un lock que ya expiró o que
+// ahora pertenece a otro propietario.
+var ErrLockNotHeld = errors.New("el lock ya no pertenece a este propietario")
+
+// Lock es un lock distribuido tomado con un token propio. Extend y Release solo
+// actúan si el lock sigue perteneciendo a ese token, por lo que un proceso cuyo
+// lock expiró nunca libera el de otro.
+type Lock interface {
+	Key() string
+	// Extend renueva la expiración del lock a ttl desde ahora.
+	Extend(ctx context.Context, ttl time.Duration) error
+	Release(ctx context.Context) error
+}
@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency es la moneda que se asume cuando un monto no indica una.
+const DefaultCurrency = "USD"
+
+// moneyScale es la cantidad de decimales de las unidades menores. Coincide con
+// las columnas DECIMAL(10,2) en las que se almacenan los montos.
+const moneyScale = 2
+
+// moneyFactor es 10^moneyScale: cuántas unidades menores tiene una unidad.
+const moneyFactor = 100
+
+var (
+	// ErrInvalidMoney se retorna cuando un texto no representa un monto decimal.
+	ErrInvalidMoney = &Error{Kind: KindValidation, Code: "invalid_money", Message: "monto inválido"}
+	// ErrCurrencyMismatch se retorna al operar montos de monedas distintas.
+	ErrCurrencyMismatch = &Error{Kind: KindValidation, Code: "currency_mismatch", Message: "los montos tienen monedas distintas"}
+)
+
+// Contextos de los errores de los montos.
+func detailMoneyValue(value string) Detail {
+	return Detail{Code: "detail_money_value", Format: ": %q", Args: []any{value}}
+}
+
+func detailMoneyOutOfRange(value string) Detail {
+	return Detail{Code: "detail_money_out_of_range", Format: ": %q fuera de rango", Args: []any{value}}
+}
+
+func detailMoneyMissingAmount() Detail {
+	return Detail{Code: "detail_money_missing_amount", Format: ": falta amount"}
+}
+
+func detailMoneyUnsupportedType(src any) Detail {
+	return Detail{Code: "detail_money_unsupported_type", Format: ": tipo %T no soportado", Args: []any{src}}
+}
+
+func detailCurrencies(a, b string) Detail {
+	return Detail{Code: "detail_currencies", Format: ": %s y %s", Args: []any{a, b}}
+}
+
+// Money representa un monto exacto como un entero de unidades menores (por
+// ejemplo centavos) más el código ISO 4217 de su moneda. Evita los errores de
+// redondeo binario de float64 al sumar y multiplicar montos.
+//
+// Reglas de redondeo: los montos solo se redondean al convertirlos desde texto
+// (ParseMoney, JSON y columnas DECIMAL); si el texto trae más de dos decimales se
+// redondea a la unidad menor más cercana y los empates se alejan de cero
+// (10.005 → 10.01, -10.005 → -10.01). Las sumas y la multiplicación por
+// cantidades enteras son exactas y nunca redondean.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// IsValidCurrency indica si code tiene la forma de un código ISO 4217: tres letras
+// mayúsculas, como USD.
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewMoney crea un monto a partir de unidades menores.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney convierte un decimal en texto ("10.50", "-3", "0.125") a Money,
+// aplicando las reglas de redondeo descritas en Money.
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	r, ok := new(big.Rat).SetString(value)
+	if !ok || value == "" || strings.ContainsAny(value, "/eE") {
+		return Money{}, ErrInvalidMoney.With(detailMoneyValue(value))
+	}
+
+	// Escalar a unidades menores y redondear alejándose de cero en los empates
+	r.Mul(r, big.NewRat(moneyFactor, 1))
+	num, den := r.Num(), r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return Money{}, ErrInvalidMoney.With(detailMoneyOutOfRange(value))
+	}
+
+	return Money{Amount: quo.Int64(), Currency: currency}, nil
+}
+
+// Add suma dos montos de la misma moneda.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch.With(detailCurrencies(m.Currency, other.Currency))
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul multiplica el monto por una cantidad entera; el resultado es exacto.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// IsPositive indica si el monto es mayor que cero.
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// String retorna el monto como decimal con dos dígitos, sin la moneda.
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/moneyFactor, moneyScale, amount%moneyFactor)
+}
+
+// moneyJSON es la representación JSON de Money. El monto se serializa como texto
+// para que los clientes no lo lean como un número de punto flotante.
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON serializa el monto como {"amount": "10.50", "currency": "USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.String())
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON acepta el objeto {"amount": ..., "currency": ...} o un monto
+// suelto; el monto puede venir como número o como texto. Un monto sin moneda
+// queda con Currency vacío para que quien lo recibe aplique la moneda por defecto.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var raw moneyJSON
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if len(raw.Amount) == 0 {
+			return ErrInvalidMoney.With(detailMoneyMissingAmount())
+		}
+
+		parsed, err := parseJSONAmount(raw.Amount)
+		if err != nil {
+			return err
+		}
+		parsed.Currency = raw.Currency
+		*m = parsed
+		return nil
+	}
+
+	parsed, err := parseJSONAmount(data)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// parseJSONAmount interpreta un número o texto JSON conservando sus decimales exactos.
+func parseJSONAmount(data []byte) (Money, error) {
+	text := string(data)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	return ParseMoney(text, "")
+}
+
+// Value permite usar Money como parámetro de una columna DECIMAL.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan lee una columna DECIMAL. Solo asigna Amount: la moneda se lee de su propia
+// columna, por ejemplo rows.Scan(&p.Price, &p.Price.Currency).
+func (m *Money) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		m.Amount = 0
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		m.Amount = v * moneyFactor
+		return nil
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ErrInvalidMoney.With(detailMoneyUnsupportedType(src))
+	}
+
+	parsed, err := ParseMoney(text, "")
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
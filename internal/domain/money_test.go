@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoneyRoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"10.50", 1050},
+		{"10.005", 1001},
+		{"-10.005", -1001},
+		{"10.004", 1000},
+		{"-10.004", -1000},
+		{"0.125", 13},
+		{"3", 300},
+		{" 12.90 ", 1290},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, "USD")
+		if err != nil {
+			t.Errorf("ParseMoney(%q): error inesperado: %v", tt.value, err)
+			continue
+		}
+		if got.Amount != tt.want || got.Currency != "USD" {
+			t.Errorf("ParseMoney(%q) = %+v, se esperaba {Amount:%d Currency:USD}", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseMoneyRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{"", "abc", "1e3", "1E3", "1/3", "10.5.0", "99999999999999999999999"} {
+		if _, err := ParseMoney(value, "USD"); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("ParseMoney(%q): error %v, se esperaba ErrInvalidMoney", value, err)
+		}
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := NewMoney(1050, "USD").Add(NewMoney(295, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != NewMoney(1345, "USD") {
+		t.Errorf("suma = %+v, se esperaba 13.45 USD", sum)
+	}
+
+	if _, err := NewMoney(1050, "USD").Add(NewMoney(100, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("sumar USD y EUR: error %v, se esperaba ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	// 0.10 * 3 es exacto en unidades menores, a diferencia de float64
+	if got := NewMoney(10, "USD").Mul(3); got != NewMoney(30, "USD") {
+		t.Errorf("0.10 * 3 = %s, se esperaba 0.30", got)
+	}
+	if got := NewMoney(1999, "USD").Mul(0); got.Amount != 0 {
+		t.Errorf("19.99 * 0 = %s, se esperaba 0.00", got)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := map[int64]string{0: "0.00", 5: "0.05", 1050: "10.50", -1001: "-10.01", -5: "-0.05"}
+	for amount, want := range tests {
+		if got := NewMoney(amount, "USD").String(); got != want {
+			t.Errorf("NewMoney(%d).String() = %q, se esperaba %q", amount, got, want)
+		}
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	original := NewMoney(1290, "EUR")
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":"12.90","currency":"EUR"}` {
+		t.Errorf("JSON = %s", data)
+	}
+
+	var decoded Money
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != original {
+		t.Errorf("ida y vuelta = %+v, se esperaba %+v", decoded, original)
+	}
+}
+
+func TestMoneyUnmarshalJSONAcceptsBareAmounts(t *testing.T) {
+	for _, input := range []string{`12.90`, `"12.90"`, `{"amount": 12.90}`} {
+		var m Money
+		if err := json.Unmarshal([]byte(input), &m); err != nil {
+			t.Errorf("%s: error inesperado: %v", input, err)
+			continue
+		}
+		if m != NewMoney(1290, "") {
+			t.Errorf("%s = %+v, se esperaba 12.90 sin moneda", input, m)
+		}
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`{"currency": "USD"}`), &m); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("objeto sin amount: error %v, se esperaba ErrInvalidMoney", err)
+	}
+}
+
+func TestIsValidCurrency(t *testing.T) {
+	for _, code := range []string{"USD", "EUR", "COP"} {
+		if !IsValidCurrency(code) {
+			t.Errorf("IsValidCurrency(%q) = false", code)
+		}
+	}
+	for _, code := range []string{"", "usd", "US", "DOLLAR", "U5D", "ÜSD"} {
+		if IsValidCurrency(code) {
+			t.Errorf("IsValidCurrency(%q) = true", code)
+		}
+	}
+}
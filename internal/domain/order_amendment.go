@@ -0,0 +1,81 @@
+package domain
+
+import "time"
+
+// ErrOrderNotAmendable se retorna al modificar los items de una orden que ya fue
+// enviada, entregada o cancelada.
+var ErrOrderNotAmendable = NewConflictError("order_not_amendable", "la orden ya no puede modificarse")
+
+// AmendOrderService contiene los items que debe tener una orden después de
+// modificarla. Los productos de la orden que no vienen en Items se retiran. Actor
+// identifica a quien modifica la orden en el historial.
+type AmendOrderService struct {
+	Actor string
+	Items []CreateOrderItemService `validate:"required,min=1,dive,required"`
+}
+
+// OrderAmendment registra una modificación de los items de una orden junto con el
+// total antes y después del cambio.
+type OrderAmendment struct {
+	ID            int
+	OrderID       int
+	Actor         string
+	PreviousTotal Money
+	NewTotal      Money
+	Changes       []OrderItemChange
+	CreatedAt     time.Time
+}
+
+// OrderItemChange es el cambio de cantidad de un producto en una modificación. Un
+// PreviousQuantity cero indica que el producto se agregó y un NewQuantity cero que
+// se retiró.
+type OrderItemChange struct {
+	ProductID        int
+	ProductName      string
+	PreviousQuantity int
+	NewQuantity      int
+}
+
+// Delta retorna cuántas unidades más (positivo) o menos (negativo) del producto
+// lleva la orden después del cambio.
+func (c OrderItemChange) Delta() int {
+	return c.NewQuantity - c.PreviousQuantity
+}
+
+// DiffOrderItems compara los items de una orden antes y después de modificarla y
+// retorna un cambio por cada producto cuya cantidad varió: primero los de after en
+// su orden y luego los retirados.
+func DiffOrderItems(before, after []OrderItem) []OrderItemChange {
+	previous := make(map[int]OrderItem, len(before))
+	for _, item := range before {
+		previous[item.ProductID] = item
+	}
+
+	var changes []OrderItemChange
+	kept := make(map[int]bool, len(after))
+	for _, item := range after {
+		kept[item.ProductID] = true
+		if item.Quantity == previous[item.ProductID].Quantity {
+			continue
+		}
+		changes = append(changes, OrderItemChange{
+			ProductID:        item.ProductID,
+			ProductName:      item.ProductName,
+			PreviousQuantity: previous[item.ProductID].Quantity,
+			NewQuantity:      item.Quantity,
+		})
+	}
+
+	for _, item := range before {
+		if kept[item.ProductID] {
+			continue
+		}
+		changes = append(changes, OrderItemChange{
+			ProductID:        item.ProductID,
+			ProductName:      item.ProductName,
+			PreviousQuantity: item.Quantity,
+		})
+	}
+
+	return changes
+}
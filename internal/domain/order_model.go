@@ -2,21 +2,38 @@ package domain
 
 import "time"
 
+// ErrDuplicateIdempotencyKey indica que ya existe un registro creado con la misma
+// Idempotency-Key.
+var ErrDuplicateIdempotencyKey = NewConflictError("duplicate_idempotency_key", "ya existe un registro con esa clave de idempotencia")
+
+// ErrOrderNotFound se retorna cuando la orden no existe.
+var ErrOrderNotFound = NewNotFoundError("order_not_found", "orden no encontrada")
+
 type Order struct {
 	ID           int
+	CustomerID   *int
 	CustomerName string
-	TotalAmount  float64
+	TotalAmount  Money
+	Status       OrderStatus
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	Items        []OrderItem
+	// IdempotencyKey es la clave de la solicitud que creó la orden; vacía si se
+	// creó sin una. No se expone en las respuestas.
+	IdempotencyKey string `json:"-"`
 }
 
+// OrderItem es un producto de la orden. ProductName y UnitPrice guardan el nombre
+// y el precio del producto al momento de la compra, por lo que no cambian si luego
+// se modifica el catálogo.
 type OrderItem struct {
-	ID        int
-	OrderID   int
-	ProductID int
-	Quantity  int
-	Subtotal  float64
+	ID          int
+	OrderID     int
+	ProductID   int
+	ProductName string
+	Quantity    int
+	UnitPrice   Money
+	Subtotal    Money
 }
 
 type CreateOrderItemService struct {
@@ -24,7 +41,73 @@ type CreateOrderItemService struct {
 	Quantity  int `validate:"required,min=1"`
 }
 
+// MergeOrderItems suma las cantidades de los items que repiten producto. Conserva
+// el orden de la primera aparición de cada producto.
+func MergeOrderItems(items []CreateOrderItemService) []CreateOrderItemService {
+	return mergeItemQuantities(items)
+}
+
+// itemQuantity es la cantidad pedida de un producto, común a los items de las
+// órdenes y de las reservas.
+type itemQuantity struct {
+	ProductID int
+	Quantity  int
+}
+
+// mergeItemQuantities suma las cantidades de los items que repiten producto y
+// conserva el orden de la primera aparición de cada uno.
+func mergeItemQuantities[T CreateOrderItemService | CreateReservationItemService](items []T) []T {
+	merged := make([]T, 0, len(items))
+	index := make(map[int]int, len(items))
+	for _, item := range items {
+		pair := itemQuantity(item)
+		if i, ok := index[pair.ProductID]; ok {
+			total := itemQuantity(merged[i])
+			total.Quantity += pair.Quantity
+			merged[i] = T(total)
+			continue
+		}
+		index[pair.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// CreateOrderService contiene los datos de una orden nueva. Si ReservationID no es
+// cero, los items se toman de esa reserva y Items debe venir vacío. Si CustomerID
+// no es cero y CustomerName viene vacío se usa el nombre del cliente. Actor
+// identifica a quien crea la orden en el historial de stock. Si IdempotencyKey no
+// viene vacía y ya existe una orden con esa clave, se retorna esa orden.
 type CreateOrderService struct {
-	CustomerName string `validate:"required"`
-	Items        []CreateOrderItemService `validate:"required,dive,required"`
+	Actor          string
+	IdempotencyKey string
+	CustomerID     int                      `validate:"min=0"`
+	CustomerName   string                   `validate:"required_without_all=ReservationID CustomerID"`
+	ReservationID  int                      `validate:"min=0"`
+	Items          []CreateOrderItemService `validate:"required_without=ReservationID,dive,required"`
+}
+
+// OrderFilter agrupa los criterios para listar órdenes. Los campos vacíos o nil
+// no filtran.
+type OrderFilter struct {
+	CustomerID   int
+	CustomerName string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	MinTotal     *Money
+	MaxTotal     *Money
+	Status       OrderStatus
+	// Sort es el campo de ordenamiento (created_at, total_amount, id); un prefijo
+	// "-" indica orden descendente.
+	Sort        string
+	Limit       int
+	Cursor      string
+	ExpandItems bool
+}
+
+// OrderPage es una página de órdenes junto con el cursor de la página siguiente,
+// vacío cuando no hay más resultados.
+type OrderPage struct {
+	Orders     []Order
+	NextCursor string
 }
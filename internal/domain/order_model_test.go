@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeItemsAddsRepeatedProducts(t *testing.T) {
+	orderItems := MergeOrderItems([]CreateOrderItemService{
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, Quantity: 4},
+	})
+	if want := []CreateOrderItemService{{ProductID: 2, Quantity: 5}, {ProductID: 1, Quantity: 3}}; !slices.Equal(orderItems, want) {
+		t.Errorf("items de la orden %v, se esperaba %v", orderItems, want)
+	}
+
+	reservationItems := MergeReservationItems([]CreateReservationItemService{
+		{ProductID: 7, Quantity: 2},
+		{ProductID: 7, Quantity: 2},
+		{ProductID: 3, Quantity: 1},
+	})
+	if want := []CreateReservationItemService{{ProductID: 7, Quantity: 4}, {ProductID: 3, Quantity: 1}}; !slices.Equal(reservationItems, want) {
+		t.Errorf("items de la reserva %v, se esperaba %v", reservationItems, want)
+	}
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// ErrInvalidOrderStatusTransition se retorna cuando se intenta mover una orden a
+// un estado que no es alcanzable desde su estado actual.
+var ErrInvalidOrderStatusTransition = NewConflictError("invalid_order_status_transition", "transición de estado de la orden no permitida")
+
+// DetailTransition indica el estado actual de la orden y el pedido.
+func DetailTransition(from, to OrderStatus) Detail {
+	return Detail{Code: "detail_transition", Format: ": %s → %s", Args: []any{from, to}}
+}
+
+// OrderStatus representa la etapa del ciclo de vida en la que se encuentra una orden.
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// orderStatusTransitions define los estados a los que puede pasar una orden
+// desde cada estado. Los estados sin destinos son finales.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {},
+	OrderStatusCancelled: {},
+}
+
+// IsValid indica si el estado es uno de los estados conocidos.
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo indica si una orden en el estado s puede pasar al estado next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAmendable indica si los items de una orden en el estado s todavía pueden
+// modificarse: solo antes de enviarse.
+func (s OrderStatus) IsAmendable() bool {
+	return s == OrderStatusPending || s == OrderStatusPaid
+}
+
+// OrderStatusTransition registra un cambio de estado de una orden.
+type OrderStatusTransition struct {
+	ID         int
+	OrderID    int
+	FromStatus OrderStatus
+	ToStatus   OrderStatus
+	CreatedAt  time.Time
+}
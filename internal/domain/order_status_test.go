@@ -0,0 +1,54 @@
+package domain
+
+import "testing"
+
+func TestOrderStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		allowed  bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPaid, OrderStatusShipped, true},
+		{OrderStatusPaid, OrderStatusCancelled, true},
+		{OrderStatusShipped, OrderStatusDelivered, true},
+
+		{OrderStatusPending, OrderStatusShipped, false},
+		{OrderStatusPending, OrderStatusDelivered, false},
+		{OrderStatusPending, OrderStatusPending, false},
+		{OrderStatusPaid, OrderStatusPending, false},
+		{OrderStatusShipped, OrderStatusPending, false},
+		{OrderStatusShipped, OrderStatusCancelled, false},
+		{OrderStatusDelivered, OrderStatusShipped, false},
+		{OrderStatusDelivered, OrderStatusCancelled, false},
+		{OrderStatusCancelled, OrderStatusPaid, false},
+		{OrderStatusCancelled, OrderStatusPending, false},
+		{OrderStatusPending, OrderStatus("archived"), false},
+		{OrderStatus("archived"), OrderStatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s → %s: permitida %v, se esperaba %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+}
+
+func TestOrderStatusIsAmendable(t *testing.T) {
+	tests := []struct {
+		status OrderStatus
+		want   bool
+	}{
+		{OrderStatusPending, true},
+		{OrderStatusPaid, true},
+		{OrderStatusShipped, false},
+		{OrderStatusDelivered, false},
+		{OrderStatusCancelled, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.IsAmendable(); got != tt.want {
+			t.Errorf("%s: modificable %v, se esperaba %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package domain
+
+const (
+	// DefaultPageLimit es la cantidad de elementos por página cuando no se indica un límite.
+	DefaultPageLimit = 20
+	// MaxPageLimit es la cantidad máxima de elementos que se retorna en una página.
+	MaxPageLimit = 100
+)
+
+// ErrInvalidCursor se retorna cuando el cursor de paginación no puede decodificarse
+// o no corresponde al ordenamiento solicitado.
+var ErrInvalidCursor = &Error{Kind: KindValidation, Code: "invalid_cursor", Message: "cursor de paginación inválido"}
+
+// NormalizeLimit aplica el límite por defecto y el máximo permitido a un tamaño de página.
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
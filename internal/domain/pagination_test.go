@@ -0,0 +1,21 @@
+package domain
+
+import "testing"
+
+func TestNormalizeLimit(t *testing.T) {
+	tests := []struct {
+		limit, want int
+	}{
+		{0, DefaultPageLimit},
+		{-5, DefaultPageLimit},
+		{1, 1},
+		{MaxPageLimit, MaxPageLimit},
+		{MaxPageLimit + 1, MaxPageLimit},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeLimit(tt.limit); got != tt.want {
+			t.Errorf("NormalizeLimit(%d) = %d, se esperaba %d", tt.limit, got, tt.want)
+		}
+	}
+}
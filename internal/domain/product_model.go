@@ -1,10 +1,75 @@
 package domain
 
+// ErrProductHasOrders se retorna cuando se intenta eliminar un producto que ya
+// forma parte de alguna orden.
+var ErrProductHasOrders = NewConflictError("product_has_orders", "el producto tiene órdenes asociadas y no puede eliminarse")
+
+// ErrProductHasStockMovements se retorna cuando se intenta eliminar un producto
+// con movimientos de stock, que forman su historial de auditoría.
+var ErrProductHasStockMovements = NewConflictError("product_has_stock_movements", "el producto tiene movimientos de stock y no puede eliminarse")
+
+// ErrProductNotFound se retorna cuando el producto no existe.
+var ErrProductNotFound = NewNotFoundError("product_not_found", "producto no encontrado")
+
+// Product es un producto del catálogo. Reserved es la parte de Stock retenida por
+// reservas activas.
 type Product struct {
 	ID        int
 	Name      string
-	Price     float64
+	Price     Money
 	Stock     int
+	Reserved  int
 	CreatedAt string
 	UpdatedAt string
+	// IdempotencyKey es la clave de la solicitud que creó el producto; vacía si se
+	// creó sin una. No se expone en las respuestas.
+	IdempotencyKey string `json:"-"`
+}
+
+// Available retorna el stock que puede venderse o reservarse.
+func (p Product) Available() int {
+	return p.Stock - p.Reserved
+}
+
+// CreateProductService contiene los datos de un producto nuevo. Si Price no trae
+// moneda se usa DefaultCurrency. Si IdempotencyKey no viene vacía y ya existe un
+// producto con esa clave, se retorna ese producto.
+type CreateProductService struct {
+	Actor          string
+	IdempotencyKey string
+	Name           string `validate:"required,max=255"`
+	Price          Money
+	Stock          int `validate:"min=0"`
+}
+
+// UpdateProductService contiene los campos modificables de un producto; los
+// campos nil se dejan sin cambios.
+type UpdateProductService struct {
+	Name  *string `validate:"omitempty,min=1,max=255"`
+	Price *Money
+}
+
+// ProductFilter agrupa los criterios para listar productos. Los campos vacíos o
+// nil no filtran.
+type ProductFilter struct {
+	// Search filtra los productos cuyo nombre contiene el texto indicado.
+	Search   string
+	MinPrice *Money
+	MaxPrice *Money
+	// InStock en true retorna solo productos con stock disponible y en false solo
+	// los agotados.
+	InStock *bool
+	// Sort es el campo de ordenamiento (id, name, price, stock, updated_at); un
+	// prefijo "-" indica orden descendente.
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+// ProductPage es una página de productos, el cursor de la página siguiente y el
+// total de productos que cumplen el filtro.
+type ProductPage struct {
+	Products   []Product
+	NextCursor string
+	Total      int
 }
@@ -0,0 +1,108 @@
+package domain
+
+import "time"
+
+var (
+	// ErrInsufficientStock se retorna cuando el stock disponible de un producto no
+	// alcanza para la cantidad solicitada.
+	ErrInsufficientStock = &Error{Kind: KindInsufficientStock, Code: "insufficient_stock", Message: "stock insuficiente"}
+	// ErrReservationNotActive se retorna al operar sobre una reserva que ya fue
+	// confirmada, liberada o que expiró.
+	ErrReservationNotActive = NewConflictError("reservation_not_active", "la reserva no está activa")
+	// ErrReservationNotFound se retorna cuando la reserva no existe.
+	ErrReservationNotFound = NewNotFoundError("reservation_not_found", "reserva no encontrada")
+)
+
+// DetailReservation identifica la reserva con el id indicado.
+func DetailReservation(id int) Detail {
+	return Detail{Code: "detail_reservation", Format: ": reserva %d", Args: []any{id}}
+}
+
+// DetailProductName indica el producto sin stock suficiente.
+func DetailProductName(name string) Detail {
+	return Detail{Code: "detail_product_name", Format: " para el producto: %s", Args: []any{name}}
+}
+
+// DetailProductUnavailable indica el producto sin stock suficiente cuando la
+// operación no distingue si el producto existe.
+func DetailProductUnavailable(productID int) Detail {
+	return Detail{Code: "detail_product_unavailable", Format: " o producto no encontrado para el producto con ID: %d", Args: []any{productID}}
+}
+
+// DetailBelowReserved indica que el nuevo stock no cubre lo reservado.
+func DetailBelowReserved(reserved int) Detail {
+	return Detail{Code: "detail_below_reserved", Format: ": el nuevo stock es menor que el reservado (%d)", Args: []any{reserved}}
+}
+
+// DetailStockAdjustment indica el ajuste que dejaría el stock de un producto por
+// debajo de cero o de lo reservado.
+func DetailStockAdjustment(delta, productID int) Detail {
+	return Detail{Code: "detail_stock_adjustment", Format: ": el ajuste de %d dejaría el stock del producto %d por debajo de cero o de lo reservado", Args: []any{delta, productID}}
+}
+
+const (
+	// DefaultReservationTTL es el tiempo que se retiene el stock si no se indica otro.
+	DefaultReservationTTL = 15 * time.Minute
+	// MaxReservationTTL es el tiempo máximo que se puede retener el stock.
+	MaxReservationTTL = 2 * time.Hour
+)
+
+// ReservationStatus representa el estado de una reserva de stock.
+type ReservationStatus string
+
+const (
+	ReservationStatusActive    ReservationStatus = "active"
+	ReservationStatusConfirmed ReservationStatus = "confirmed"
+	ReservationStatusReleased  ReservationStatus = "released"
+	ReservationStatusExpired   ReservationStatus = "expired"
+)
+
+// Reservation retiene stock para un cliente durante un tiempo limitado. Mientras
+// está activa reduce el stock disponible de sus productos sin reducir el stock
+// físico; al confirmarse se convierte en una orden.
+type Reservation struct {
+	ID           int
+	CustomerName string
+	Status       ReservationStatus
+	ExpiresAt    time.Time
+	OrderID      *int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Items        []ReservationItem
+	// IdempotencyKey es la clave de la solicitud que creó la reserva; vacía si se
+	// creó sin una. No se expone en las respuestas.
+	IdempotencyKey string `json:"-"`
+}
+
+// IsActiveAt indica si la reserva sigue reteniendo stock en el instante indicado.
+func (r Reservation) IsActiveAt(now time.Time) bool {
+	return r.Status == ReservationStatusActive && now.Before(r.ExpiresAt)
+}
+
+type ReservationItem struct {
+	ID            int
+	ReservationID int
+	ProductID     int
+	Quantity      int
+}
+
+type CreateReservationItemService struct {
+	ProductID int `validate:"required"`
+	Quantity  int `validate:"required,min=1"`
+}
+
+// MergeReservationItems suma las cantidades de los items que repiten producto.
+// Conserva el orden de la primera aparición de cada producto.
+func MergeReservationItems(items []CreateReservationItemService) []CreateReservationItemService {
+	return mergeItemQuantities(items)
+}
+
+// CreateReservationService contiene los datos de una reserva nueva. Si
+// IdempotencyKey no viene vacía y ya existe una reserva con esa clave, se retorna
+// esa reserva.
+type CreateReservationService struct {
+	IdempotencyKey string
+	CustomerName   string                         `validate:"required"`
+	TTL            time.Duration                  `validate:"min=0"`
+	Items          []CreateReservationItemService `validate:"required,dive,required"`
+}
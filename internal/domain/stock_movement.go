@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// ErrStockMovementNotFound se retorna cuando el movimiento de stock no existe.
+var ErrStockMovementNotFound = NewNotFoundError("stock_movement_not_found", "movimiento de stock no encontrado")
+
+// StockMovementReason indica por qué cambió el stock de un producto.
+type StockMovementReason string
+
+const (
+	StockMovementOrder            StockMovementReason = "order"
+	StockMovementCancellation     StockMovementReason = "cancellation"
+	StockMovementAmendment        StockMovementReason = "amendment"
+	StockMovementManualAdjustment StockMovementReason = "manual_adjustment"
+	StockMovementRestock          StockMovementReason = "restock"
+	StockMovementCorrection       StockMovementReason = "correction"
+)
+
+// AnonymousActor identifica los cambios hechos por clientes que no se identificaron.
+const AnonymousActor = "anonymous"
+
+// StockMovement es una entrada del historial de stock de un producto. Delta es el
+// cambio aplicado (negativo si el stock bajó) y Balance el stock resultante.
+type StockMovement struct {
+	ID        int
+	ProductID int
+	Delta     int
+	Balance   int
+	Reason    StockMovementReason
+	Actor     string
+	OrderID   *int
+	Note      string
+	CreatedAt time.Time
+	// IdempotencyKey es la clave de la solicitud que registró un ajuste manual;
+	// vacía en los demás movimientos. No se expone en las respuestas.
+	IdempotencyKey string `json:"-"`
+}
+
+// StockMovementPage es una página del historial de stock de un producto, del
+// movimiento más reciente al más antiguo.
+type StockMovementPage struct {
+	Movements  []StockMovement
+	NextCursor string
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Tx es una transacción abierta por un repositorio. Los callbacks que la reciben
+// deben pasarla tal cual a los métodos *WithTransaction del mismo almacenamiento,
+// que son los únicos que conocen su implementación concreta.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
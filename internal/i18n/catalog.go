@@ -0,0 +1,274 @@
+package i18n
+
+// messages traduce los errores por su código. Los códigos son los de los errores
+// del dominio (internal/domain) y los de la API (api/problem).
+var messages = map[string]map[Language]string{
+	// Solicitud
+	"invalid_json": {
+		Spanish: "Error en el formato de los datos enviados: %s",
+		English: "Malformed request body: %s",
+	},
+	"unknown_field": {
+		Spanish: "Se enviaron campos no esperados en el cuerpo de la solicitud: %s",
+		English: "The request body contains unexpected fields: %s",
+	},
+	"unreadable_body": {
+		Spanish: "Error leyendo el cuerpo de la solicitud",
+		English: "The request body could not be read",
+	},
+	"request_body_too_large": {
+		Spanish: "El cuerpo de la solicitud no puede superar los %d bytes",
+		English: "The request body cannot be larger than %d bytes",
+	},
+	"invalid_id": {
+		Spanish: "El ID %q no es un número válido",
+		English: "The ID %q is not a valid number",
+	},
+	"invalid_query_parameter": {
+		Spanish: "Parámetro %s inválido",
+		English: "Invalid %s query parameter",
+	},
+	"validation_failed": {
+		Spanish: "Estructura de datos inválidos",
+		English: "The request data is invalid",
+	},
+	"internal_error": {
+		Spanish: "Error interno del servidor",
+		English: "Internal server error",
+	},
+	"rule_default": {
+		Spanish: "no cumple la regla %s",
+		English: "does not satisfy the %s rule",
+	},
+
+	// Idempotencia
+	"idempotency_key_required": {
+		Spanish: "Idempotency-Key es requerido",
+		English: "The Idempotency-Key header is required",
+	},
+	"idempotency_key_too_long": {
+		Spanish: "Idempotency-Key no puede superar los %d caracteres",
+		English: "Idempotency-Key cannot be longer than %d characters",
+	},
+	"idempotency_key_reused": {
+		Spanish: "Idempotency-Key ya se usó con una solicitud diferente",
+		English: "Idempotency-Key was already used with a different request",
+	},
+	"request_in_progress": {
+		Spanish: "Solicitud en progreso",
+		English: "A request with this Idempotency-Key is still in progress",
+	},
+	"duplicate_idempotency_key": {
+		Spanish: "Ya existe un registro con esa clave de idempotencia",
+		English: "A record with this idempotency key already exists",
+	},
+
+	// Stock
+	"insufficient_stock": {
+		Spanish: "Stock insuficiente",
+		English: "Insufficient stock",
+	},
+	"resource_locked": {
+		Spanish: "El producto está siendo modificado por otra operación, intente nuevamente",
+		English: "The product is being modified by another operation, please retry",
+	},
+
+	// Recursos
+	"customer_not_found": {
+		Spanish: "Cliente no encontrado",
+		English: "Customer not found",
+	},
+	"product_not_found": {
+		Spanish: "Producto no encontrado",
+		English: "Product not found",
+	},
+	"order_not_found": {
+		Spanish: "Orden no encontrada",
+		English: "Order not found",
+	},
+	"reservation_not_found": {
+		Spanish: "Reserva no encontrada",
+		English: "Reservation not found",
+	},
+	"stock_movement_not_found": {
+		Spanish: "Movimiento de stock no encontrado",
+		English: "Stock movement not found",
+	},
+	"customer_email_taken": {
+		Spanish: "Ya existe un cliente con ese email",
+		English: "A customer with this email already exists",
+	},
+	"customer_has_orders": {
+		Spanish: "El cliente tiene órdenes asociadas y no puede eliminarse",
+		English: "The customer has orders and cannot be deleted",
+	},
+	"product_has_orders": {
+		Spanish: "El producto tiene órdenes asociadas y no puede eliminarse",
+		English: "The product has orders and cannot be deleted",
+	},
+	"product_has_stock_movements": {
+		Spanish: "El producto tiene movimientos de stock y no puede eliminarse",
+		English: "The product has stock movements and cannot be deleted",
+	},
+	"invalid_order_status_transition": {
+		Spanish: "Transición de estado de la orden no permitida",
+		English: "Order status transition not allowed",
+	},
+	"order_not_amendable": {
+		Spanish: "La orden ya no puede modificarse",
+		English: "The order can no longer be amended",
+	},
+	"reservation_not_active": {
+		Spanish: "La reserva no está activa",
+		English: "The reservation is not active",
+	},
+	"invalid_money": {
+		Spanish: "Monto inválido",
+		English: "Invalid amount",
+	},
+	"currency_mismatch": {
+		Spanish: "Los montos tienen monedas distintas",
+		English: "The amounts have different currencies",
+	},
+	"invalid_cursor": {
+		Spanish: "Cursor de paginación inválido",
+		English: "Invalid pagination cursor",
+	},
+
+	// Contexto de los errores del dominio (domain.Detail), que se agrega al mensaje
+	// del error
+	"detail_id": {
+		Spanish: " con ID %d",
+		English: " with ID %d",
+	},
+	"detail_status": {
+		Spanish: ": estado %s",
+		English: ": status %s",
+	},
+	"detail_transition": {
+		Spanish: ": %s → %s",
+		English: ": %s → %s",
+	},
+	"detail_reservation": {
+		Spanish: ": reserva %d",
+		English: ": reservation %d",
+	},
+	"detail_product_name": {
+		Spanish: " para el producto: %s",
+		English: " for product: %s",
+	},
+	"detail_product_unavailable": {
+		Spanish: " o producto no encontrado para el producto con ID: %d",
+		English: " or product not found for the product with ID %d",
+	},
+	"detail_below_reserved": {
+		Spanish: ": el nuevo stock es menor que el reservado (%d)",
+		English: ": the new stock is below the reserved quantity (%d)",
+	},
+	"detail_stock_adjustment": {
+		Spanish: ": el ajuste de %d dejaría el stock del producto %d por debajo de cero o de lo reservado",
+		English: ": an adjustment of %d would leave the stock of product %d below zero or below the reserved quantity",
+	},
+	"detail_locks_changed": {
+		Spanish: ": los productos cambiaron después de tomar los locks, intente nuevamente",
+		English: ": the products changed after their locks were taken, try again",
+	},
+	"detail_money_value": {
+		Spanish: ": %q",
+		English: ": %q",
+	},
+	"detail_money_out_of_range": {
+		Spanish: ": %q fuera de rango",
+		English: ": %q is out of range",
+	},
+	"detail_money_missing_amount": {
+		Spanish: ": falta amount",
+		English: ": amount is missing",
+	},
+	"detail_money_unsupported_type": {
+		Spanish: ": tipo %T no soportado",
+		English: ": unsupported type %T",
+	},
+	"detail_currencies": {
+		Spanish: ": %s y %s",
+		English: ": %s and %s",
+	},
+}
+
+// ruleMessages traduce las reglas de validación por su tag de validator. Las
+// variantes _characters e _items se usan cuando la regla limita la longitud de un
+// texto o de una lista; %s es el parámetro de la regla.
+var ruleMessages = map[string]map[Language]string{
+	"required": {
+		Spanish: "es requerido",
+		English: "is required",
+	},
+	"required_without": {
+		Spanish: "es requerido",
+		English: "is required",
+	},
+	"required_without_all": {
+		Spanish: "es requerido",
+		English: "is required",
+	},
+	"excluded_with": {
+		Spanish: "no puede enviarse junto con %s",
+		English: "cannot be sent together with %s",
+	},
+	"email": {
+		Spanish: "debe ser un email válido",
+		English: "must be a valid email",
+	},
+	"iso4217": {
+		Spanish: "debe ser un código de moneda ISO 4217 de tres letras mayúsculas",
+		English: "must be an ISO 4217 currency code of three uppercase letters",
+	},
+	"oneof": {
+		Spanish: "debe ser uno de: %s",
+		English: "must be one of: %s",
+	},
+	"len": {
+		Spanish: "debe ser igual a %s",
+		English: "must be equal to %s",
+	},
+	"len_characters": {
+		Spanish: "debe tener exactamente %s caracteres",
+		English: "must have exactly %s characters",
+	},
+	"len_items": {
+		Spanish: "debe tener exactamente %s elementos",
+		English: "must have exactly %s items",
+	},
+	"min": {
+		Spanish: "debe ser mayor o igual a %s",
+		English: "must be greater than or equal to %s",
+	},
+	"min_characters": {
+		Spanish: "debe tener al menos %s caracteres",
+		English: "must have at least %s characters",
+	},
+	"min_items": {
+		Spanish: "debe tener al menos %s elementos",
+		English: "must have at least %s items",
+	},
+	"max": {
+		Spanish: "debe ser menor o igual a %s",
+		English: "must be less than or equal to %s",
+	},
+	"max_characters": {
+		Spanish: "debe tener como máximo %s caracteres",
+		English: "must have at most %s characters",
+	},
+	"max_items": {
+		Spanish: "debe tener como máximo %s elementos",
+		English: "must have at most %s items",
+	},
+	"gt": {
+		Spanish: "debe ser mayor que %s",
+		English: "must be greater than %s",
+	},
+	"ne": {
+		Spanish: "no puede ser %s",
+		English: "cannot be %s",
+	},
+}
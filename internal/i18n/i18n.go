@@ -0,0 +1,139 @@
+// Package i18n traduce los mensajes que la API muestra a los clientes. Los mensajes
+// se identifican por el código estable del error y el idioma se elige con el
+// encabezado Accept-Language.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language es un idioma soportado, identificado por su código ISO 639-1.
+type Language string
+
+const (
+	Spanish Language = "es"
+	English Language = "en"
+)
+
+// Default es el idioma de los mensajes cuando el cliente no pide uno soportado.
+const Default = Spanish
+
+// Languages son los idiomas soportados.
+var Languages = []Language{Spanish, English}
+
+// Parse elige el idioma soportado con mayor preferencia en un encabezado
+// Accept-Language, por ejemplo "en-US,en;q=0.9,es;q=0.8". Las variantes regionales
+// se tratan como su idioma base y, si ninguno es soportado, retorna Default.
+func Parse(acceptLanguage string) Language {
+	type candidate struct {
+		lang Language
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if base == "*" {
+			candidates = append(candidates, candidate{Default, q})
+			continue
+		}
+		if lang := Language(base); lang.supported() {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Default
+	}
+
+	// A igual preferencia gana el que aparece primero
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+func (l Language) supported() bool {
+	for _, lang := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// Message retorna el mensaje de code en lang con args aplicados al formato. Si el
+// mensaje no está traducido a lang se usa Default, y si code no está en el
+// catálogo retorna "" para que quien llama use su propio mensaje.
+func Message(lang Language, code string, args ...any) string {
+	translations, ok := messages[code]
+	if !ok {
+		return ""
+	}
+
+	format, ok := translations[lang]
+	if !ok {
+		format = translations[Default]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Rule describe una regla de validación incumplida por un campo.
+type Rule struct {
+	// Tag es la regla de validator, por ejemplo max.
+	Tag string
+	// Param es el parámetro de la regla, por ejemplo 255.
+	Param string
+	// Unit indica si min, max y len miden la longitud de un texto (UnitCharacters)
+	// o de una lista (UnitItems); vacío si miden un valor numérico.
+	Unit string
+}
+
+// Unidades de las reglas que miden longitudes.
+const (
+	UnitCharacters = "characters"
+	UnitItems      = "items"
+)
+
+// FieldMessage describe en lang la regla que incumplió un campo.
+func FieldMessage(lang Language, rule Rule) string {
+	translations, ok := ruleMessages[rule.Tag]
+	if rule.Unit != "" {
+		if unitTranslations, found := ruleMessages[rule.Tag+"_"+rule.Unit]; found {
+			translations, ok = unitTranslations, true
+		}
+	}
+	if !ok {
+		return Message(lang, "rule_default", rule.Tag)
+	}
+
+	format, ok := translations[lang]
+	if !ok {
+		format = translations[Default]
+	}
+
+	// Las reglas con varios valores, como oneof, los listan separados por comas
+	param := strings.Join(strings.Fields(rule.Param), ", ")
+	if strings.Contains(format, "%s") {
+		return fmt.Sprintf(format, param)
+	}
+	return format
+}
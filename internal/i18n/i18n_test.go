@@ -0,0 +1,62 @@
+package i18n
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Language
+	}{
+		{"", Spanish},
+		{"en", English},
+		{"en-US", English},
+		{"EN-gb", English},
+		{"es-MX,en;q=0.5", Spanish},
+		{"en-US,en;q=0.9,es;q=0.8", English},
+		{"es;q=0.4, en;q=0.7", English},
+		{"en;q=0.5, es;q=0.5", English},
+		{"en;q=0, es;q=0.1", Spanish},
+		{"fr-FR,de;q=0.9", Spanish},
+		{"fr, en;q=0.3", English},
+		{"en;q=abc, es;q=0.2", Spanish},
+		{"*", Default},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.header); got != tt.want {
+			t.Errorf("Parse(%q) = %s, se esperaba %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		lang Language
+		code string
+		args []any
+		want string
+	}{
+		{English, "order_not_found", nil, "Order not found"},
+		{Spanish, "detail_id", []any{7}, " con ID 7"},
+		{English, "detail_id", []any{7}, " with ID 7"},
+		{English, "detail_transition", []any{"pending", "delivered"}, ": pending → delivered"},
+		{Language("fr"), "order_not_found", nil, "Orden no encontrada"},
+		{English, "codigo_inexistente", nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Message(tt.lang, tt.code, tt.args...); got != tt.want {
+			t.Errorf("Message(%s, %s) = %q, se esperaba %q", tt.lang, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogIsTranslated(t *testing.T) {
+	for code, translations := range messages {
+		for _, lang := range Languages {
+			if translations[lang] == "" {
+				t.Errorf("%s no está traducido a %s", code, lang)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// releaseScript borra la clave solo si su valor sigue siendo el token del propietario.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendScript renueva la expiración (en milisegundos) solo si la clave sigue
+// perteneciendo al propietario.
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// TryLock intenta tomar el lock una sola vez guardando un token aleatorio como
+// valor. Retorna domain.ErrLockNotAcquired si la clave ya existe.
+func (r *RedisClient) TryLock(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	acquired, err := r.Client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, domain.ErrLockNotAcquired
+	}
+
+	return &redisLock{client: r.Client, key: key, token: token}, nil
+}
+
+// redisLock es un lock tomado en Redis por un propietario identificado por token.
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+func (l *redisLock) Key() string {
+	return l.key
+}
+
+func (l *redisLock) Extend(ctx context.Context, ttl time.Duration) error {
+	extended, err := extendScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if extended == 0 {
+		return domain.ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	released, err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int()
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return domain.ErrLockNotHeld
+	}
+	return nil
+}
+
+// newLockToken genera el token aleatorio que identifica al propietario de un lock.
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
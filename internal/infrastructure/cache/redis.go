@@ -1,12 +1,14 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/go-redis/redis/v8"
-	"golang.org/x/net/context"
 )
 
 type RedisClient struct {
@@ -23,14 +25,37 @@ func NewRedisClient(host, port, password string, db int) *RedisClient {
 	return &RedisClient{Client: rdb}
 }
 
+// Ping verifica que Redis responda.
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
+}
+
+// ErrIdempotencyKeyNotFound se retorna cuando la clave de idempotencia no existe.
+var ErrIdempotencyKeyNotFound = errors.New("clave de idempotencia no encontrada")
+
+// Estados de una solicitud con Idempotency-Key.
+const (
+	IdempotencyStatusInProgress = "IN_PROGRESS"
+	IdempotencyStatusCompleted  = "COMPLETED"
+)
+
+// IdempotencyData es el estado de una solicitud con Idempotency-Key. Mientras está
+// en curso su dueño renueva LeaseExpiresAt; al completarse guarda la respuesta
+// completa para repetirla sin cambios.
 type IdempotencyData struct {
-	Status   string `json:"status"`   // IN_PROGRESS o COMPLETED
-	Response string `json:"response"` // Respuesta generada
+	Status         string      `json:"status"`                    // IN_PROGRESS o COMPLETED
+	Fingerprint    string      `json:"fingerprint"`               // Hash del método, la ruta y el cuerpo
+	LeaseExpiresAt time.Time   `json:"lease_expires_at,omitzero"` // Vencimiento de la lease de IN_PROGRESS
+	StatusCode     int         `json:"status_code,omitempty"`     // Código HTTP de la respuesta
+	Header         http.Header `json:"header,omitempty"`          // Encabezados de la respuesta
+	Body           []byte      `json:"body,omitempty"`            // Cuerpo de la respuesta
 }
 
-func (r *RedisClient) GetIdempotencyKey(key string) (*IdempotencyData, error) {
-	ctx := context.Background()
+func (r *RedisClient) GetIdempotencyKey(ctx context.Context, key string) (*IdempotencyData, error) {
 	data, err := r.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrIdempotencyKeyNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -43,28 +68,56 @@ func (r *RedisClient) GetIdempotencyKey(key string) (*IdempotencyData, error) {
 	return &idempotencyData, nil
 }
 
-func (r *RedisClient) SetIdempotencyKey(key string, data IdempotencyData, expiration time.Duration) error {
-	ctx := context.Background()
+// replaceScript reemplaza el valor y su expiración (en milisegundos) solo si sigue
+// siendo el esperado.
+var replaceScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return false
+`)
+
+// CreateIdempotencyKey guarda data solo si la clave no existe y retorna false si ya
+// existía, de modo que dos solicitudes con la misma clave no se procesen a la vez.
+func (r *RedisClient) CreateIdempotencyKey(ctx context.Context, key string, data IdempotencyData, expiration time.Duration) (bool, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return r.Client.Set(ctx, key, jsonData, expiration).Err()
+	return r.Client.SetNX(ctx, key, jsonData, expiration).Result()
 }
 
-func (r *RedisClient) DeleteIdempotencyKey(key string) error {
-    ctx := context.Background()
-    return r.Client.Del(ctx, key).Err()
-}
+// ReplaceIdempotencyKey guarda data solo si el valor actual de la clave es old y
+// retorna false si cambió o ya no existe.
+func (r *RedisClient) ReplaceIdempotencyKey(ctx context.Context, key string, old, data IdempotencyData, expiration time.Duration) (bool, error) {
+	oldJSON, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
 
-func (r *RedisClient) AcquireLock(key string, expiration time.Duration) (bool, error) {
-    ctx := context.Background()
-    result, err := r.Client.SetNX(ctx, key, "locked", expiration).Result()
-    return result, err
+	err = replaceScript.Run(ctx, r.Client, []string{key}, oldJSON, jsonData, expiration.Milliseconds()).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
-func (r *RedisClient) ReleaseLock(key string) error {
-    ctx := context.Background()
-    return r.Client.Del(ctx, key).Err()
-}
\ No newline at end of file
+// ReleaseIdempotencyKey borra la clave solo si su valor actual es old y retorna
+// false si cambió o ya no existe.
+func (r *RedisClient) ReleaseIdempotencyKey(ctx context.Context, key string, old IdempotencyData) (bool, error) {
+	oldJSON, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := releaseScript.Run(ctx, r.Client, []string{key}, oldJSON).Int()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
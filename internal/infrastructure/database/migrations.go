@@ -1,85 +1,409 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// RunMigrations ejecuta la creación de la base de datos y las tablas
-func RunMigrations(db *sql.DB) {
-	queries := []string{
-		`CREATE DATABASE IF NOT EXISTS order_management;`,
-		`USE order_management;`,
-		`CREATE TABLE IF NOT EXISTS products (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			name VARCHAR(255) NOT NULL,
-			price DECIMAL(10,2) NOT NULL,
-			stock INT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-		);`,
-		`CREATE TABLE IF NOT EXISTS orders (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			customer_name VARCHAR(255) NOT NULL,
-			total_amount DECIMAL(10,2) NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-		);`,
-		`CREATE TABLE IF NOT EXISTS order_items (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			order_id INT NOT NULL,
-			product_id INT NOT NULL,
-			quantity INT NOT NULL,
-			subtotal DECIMAL(10,2) NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-			FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
-			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
-		);`,
-	}
-
-	for _, query := range queries {
-		_, err := db.Exec(query)
+// migrationFiles contiene los scripts versionados. Cada versión tiene un archivo
+// NNNN_nombre.up.sql y, si es reversible, su NNNN_nombre.down.sql.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockName es el nombre del lock de MySQL que evita que dos procesos
+// apliquen migraciones al mismo tiempo.
+const migrationLockName = "order_management.schema_migrations"
+
+// migrationLockTimeout es el tiempo máximo que se espera por el lock de migraciones.
+const migrationLockTimeout = 30 * time.Second
+
+var (
+	// ErrMigrationLocked se retorna cuando otro proceso está aplicando migraciones.
+	ErrMigrationLocked = errors.New("otro proceso está ejecutando migraciones")
+	// ErrChecksumMismatch se retorna cuando un script ya aplicado fue modificado.
+	ErrChecksumMismatch = errors.New("el checksum de una migración aplicada no coincide con su archivo")
+	// ErrIrreversibleMigration se retorna al revertir una migración sin script down.
+	ErrIrreversibleMigration = errors.New("la migración no tiene script down")
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration es una versión del esquema con sus scripts de subida y bajada.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Checksum es el SHA-256 del script up; detecta cambios en migraciones ya aplicadas.
+	Checksum string
+}
+
+// MigrationStatus describe el estado de una migración en la base de datos.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	// Modified indica que el script up cambió después de aplicarse.
+	Modified bool
+}
+
+type appliedMigration struct {
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator aplica y revierte las migraciones versionadas registrando cada versión
+// aplicada en la tabla schema_migrations.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+// NewMigrator crea un Migrator con las migraciones embebidas en el binario.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// LoadMigrations lee los scripts del directorio dir y los retorna ordenados por versión.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nombre de migración inválido: %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("versión de migración inválida: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
 		if err != nil {
-			log.Fatalf("Error ejecutando la migración: %v", err)
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("la versión %d tiene nombres distintos: %s y %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("la migración %d_%s no tiene script up", m.Version, m.Name)
 		}
+		migrations = append(migrations, *m)
 	}
 
-	fmt.Println("Migraciones ejecutadas correctamente ✅")
-	
-    // Insertar datos de prueba en la tabla products si no existen
-    insertTestData(db)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
 }
 
-// insertTestData inserta datos de prueba en la tabla products si está vacía
-func insertTestData(db *sql.DB) {
-	// Verificar si la tabla products ya tiene datos
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM products").Scan(&count)
-	if err != nil {
-		log.Fatalf("Error verificando datos en la tabla products: %v", err)
+// Up aplica en orden todas las migraciones pendientes y retorna las aplicadas.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		adopted := false
+		for _, migration := range m.Migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			// Las migraciones posteriores al esquema inicial dependen de sus columnas
+			if migration.Version > initialSchemaVersion && !adopted {
+				if err := adoptLegacySchema(ctx, conn); err != nil {
+					return fmt.Errorf("error adoptando el esquema previo a las migraciones: %w", err)
+				}
+				adopted = true
+			}
+
+			if err := execScript(ctx, conn, migration.Up); err != nil {
+				return fmt.Errorf("error aplicando la migración %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err := conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, migration.Checksum); err != nil {
+				return err
+			}
+
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revierte las últimas n migraciones aplicadas, de la más reciente a la más
+// antigua, y retorna las revertidas.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n <= 0 {
+		return nil, errors.New("la cantidad de migraciones a revertir debe ser mayor que cero")
 	}
 
-	// Si no hay datos, insertar productos de prueba
-	if count == 0 {
-		fmt.Println("Insertando datos de prueba en la tabla products...")
+	var reverted []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.Migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			migration := m.Migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversibleMigration, migration.Version, migration.Name)
+			}
+
+			if err := execScript(ctx, conn, migration.Down); err != nil {
+				return fmt.Errorf("error revirtiendo la migración %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err := conn.ExecContext(ctx,
+				"DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+				return err
+			}
+
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status retorna el estado de cada migración conocida sin aplicar cambios.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
 
-		queries := []string{
-			`INSERT INTO products (name, price, stock) VALUES ('Producto A', 10.50, 100);`,
-			`INSERT INTO products (name, price, stock) VALUES ('Producto B', 20.00, 50);`,
-			`INSERT INTO products (name, price, stock) VALUES ('Producto C', 15.75, 200);`,
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
 		}
 
-		for _, query := range queries {
-			_, err := db.Exec(query)
-			if err != nil {
-				log.Fatalf("Error insertando datos de prueba: %v", err)
+		for _, migration := range m.Migrations {
+			status := MigrationStatus{Migration: migration}
+			if applied, ok := done[migration.Version]; ok {
+				appliedAt := applied.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.Modified = applied.Checksum != migration.Checksum
 			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// initialSchemaVersion es la versión de la migración que crea el esquema inicial.
+const initialSchemaVersion = 1
+
+// legacyColumn es una columna del esquema inicial que las bases creadas antes de
+// las migraciones versionadas pueden no tener.
+type legacyColumn struct {
+	Table      string
+	Column     string
+	Definition string
+}
+
+// legacyColumns son las columnas que RunMigrations agregaba a las tablas después
+// de crearlas. La migración inicial usa CREATE TABLE IF NOT EXISTS, por lo que en
+// esas bases no modifica las tablas existentes y las columnas quedan sin crear.
+var legacyColumns = []legacyColumn{
+	{"products", "currency", "CHAR(3) NOT NULL DEFAULT 'USD' AFTER price"},
+	{"products", "reserved", "INT NOT NULL DEFAULT 0 AFTER stock"},
+	{"orders", "customer_id", "INT NULL AFTER id, ADD INDEX idx_orders_customer_id (customer_id), ADD FOREIGN KEY (customer_id) REFERENCES customers(id)"},
+	{"orders", "currency", "CHAR(3) NOT NULL DEFAULT 'USD' AFTER total_amount"},
+	{"orders", "status", "VARCHAR(20) NOT NULL DEFAULT 'pending' AFTER currency"},
+}
+
+// adoptLegacySchema agrega las columnas de legacyColumns que falten. En las bases
+// creadas por la migración inicial todas existen y no hace cambios. MySQL no
+// soporta ADD COLUMN IF NOT EXISTS, por lo que se consulta information_schema.
+func adoptLegacySchema(ctx context.Context, conn *sql.Conn) error {
+	for _, c := range legacyColumns {
+		var count int
+		err := conn.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+			c.Table, c.Column).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.Table, c.Column, c.Definition)); err != nil {
+			return fmt.Errorf("error agregando la columna %s.%s: %w", c.Table, c.Column, err)
+		}
+	}
+	return nil
+}
+
+// withLock ejecuta fn con una conexión dedicada que mantiene el lock de migraciones.
+// GET_LOCK pertenece a la sesión, por lo que todo el trabajo usa la misma conexión.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&acquired); err != nil {
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return ErrMigrationLocked
+	}
+	defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// verify carga las migraciones aplicadas y comprueba que sus scripts no hayan
+// cambiado y que todas sigan existiendo en el binario.
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	done, err := loadApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, applied := range done {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("la versión %d está aplicada pero no existe su archivo de migración", version)
+		}
+		if applied.Checksum != migration.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
 		}
+	}
+
+	return done, nil
+}
+
+// loadApplied retorna las versiones registradas en schema_migrations.
+func loadApplied(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		fmt.Println("Datos de prueba insertados correctamente ✅")
-	} else {
-		fmt.Println("La tabla products ya contiene datos, no se insertaron datos de prueba.")
+	done := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var applied appliedMigration
+		if err := rows.Scan(&version, &applied.Checksum, &applied.AppliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = applied
 	}
+
+	return done, rows.Err()
+}
+
+// execScript ejecuta una a una las sentencias de un script. MySQL confirma
+// implícitamente cada sentencia DDL, así que un fallo a mitad del script puede
+// requerir una corrección manual antes de reintentar.
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements separa un script en sentencias terminadas en ";" al final de
+// línea, descartando las líneas de comentario "--".
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
 }
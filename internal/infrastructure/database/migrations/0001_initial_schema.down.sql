@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS stock_reservation_items;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS order_status_transitions;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS customer_addresses;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,5 @@
+-- Elimina los productos de prueba que no se hayan usado en órdenes.
+
+DELETE FROM products
+WHERE name IN ('Producto A', 'Producto B', 'Producto C')
+  AND id NOT IN (SELECT product_id FROM order_items);
@@ -0,0 +1,10 @@
+-- Productos de prueba; solo se insertan si el catálogo está vacío.
+
+INSERT INTO products (name, price, stock)
+SELECT seed.name, seed.price, seed.stock
+FROM (
+    SELECT 'Producto A' AS name, 10.50 AS price, 100 AS stock
+    UNION ALL SELECT 'Producto B', 20.00, 50
+    UNION ALL SELECT 'Producto C', 15.75, 200
+) AS seed
+WHERE NOT EXISTS (SELECT 1 FROM products);
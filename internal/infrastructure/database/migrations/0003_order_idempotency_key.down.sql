@@ -0,0 +1,5 @@
+-- Elimina la Idempotency-Key de las órdenes.
+
+ALTER TABLE orders
+    DROP INDEX idx_orders_idempotency_key,
+    DROP COLUMN idempotency_key;
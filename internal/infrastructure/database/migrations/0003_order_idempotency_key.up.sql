@@ -0,0 +1,6 @@
+-- Guarda la Idempotency-Key con la que se creó cada orden para reconciliar las
+-- solicitudes que se interrumpieron después de confirmar la transacción.
+
+ALTER TABLE orders
+    ADD COLUMN idempotency_key VARCHAR(255) NULL AFTER status,
+    ADD UNIQUE INDEX idx_orders_idempotency_key (idempotency_key);
@@ -0,0 +1,5 @@
+-- Elimina el nombre y el precio unitario guardados en los items de las órdenes.
+
+ALTER TABLE order_items
+    DROP COLUMN unit_price,
+    DROP COLUMN product_name;
@@ -0,0 +1,14 @@
+-- Guarda en cada item de la orden el nombre y el precio unitario del producto al
+-- momento de la compra, para que los cambios posteriores del catálogo no alteren
+-- las órdenes existentes.
+
+ALTER TABLE order_items
+    ADD COLUMN product_name VARCHAR(255) NOT NULL DEFAULT '' AFTER product_id,
+    ADD COLUMN unit_price DECIMAL(10,2) NOT NULL DEFAULT 0 AFTER quantity;
+
+-- Los items existentes toman el nombre actual del producto y el precio unitario
+-- que resulta de su subtotal.
+UPDATE order_items oi
+    JOIN products p ON p.id = oi.product_id
+SET oi.product_name = p.name,
+    oi.unit_price = ROUND(oi.subtotal / oi.quantity, 2);
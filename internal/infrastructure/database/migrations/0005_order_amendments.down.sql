@@ -0,0 +1,4 @@
+-- Elimina el historial de modificaciones de las órdenes.
+
+DROP TABLE IF EXISTS order_amendment_items;
+DROP TABLE IF EXISTS order_amendments;
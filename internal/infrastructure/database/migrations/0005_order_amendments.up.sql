@@ -0,0 +1,24 @@
+-- Historial de las modificaciones de items de las órdenes antes de su envío. Cada
+-- modificación guarda el total anterior y el nuevo, y un registro por producto
+-- cuya cantidad cambió.
+
+CREATE TABLE IF NOT EXISTS order_amendments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    previous_total DECIMAL(10,2) NOT NULL,
+    new_total DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_order_amendments_order (order_id, id),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS order_amendment_items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    amendment_id INT NOT NULL,
+    product_id INT NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    previous_quantity INT NOT NULL,
+    new_quantity INT NOT NULL,
+    FOREIGN KEY (amendment_id) REFERENCES order_amendments(id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Elimina la Idempotency-Key de los productos, clientes, reservas y movimientos.
+
+ALTER TABLE stock_movements
+    DROP INDEX idx_stock_movements_idempotency_key,
+    DROP COLUMN idempotency_key;
+
+ALTER TABLE stock_reservations
+    DROP INDEX idx_stock_reservations_idempotency_key,
+    DROP COLUMN idempotency_key;
+
+ALTER TABLE customers
+    DROP INDEX idx_customers_idempotency_key,
+    DROP COLUMN idempotency_key;
+
+ALTER TABLE products
+    DROP INDEX idx_products_idempotency_key,
+    DROP COLUMN idempotency_key;
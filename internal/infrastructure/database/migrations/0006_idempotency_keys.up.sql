@@ -0,0 +1,19 @@
+-- Guarda la Idempotency-Key con la que se crearon los productos, los clientes, las
+-- reservas y los ajustes de stock para reconciliar las solicitudes que se
+-- interrumpieron después de confirmar la transacción, igual que en las órdenes.
+
+ALTER TABLE products
+    ADD COLUMN idempotency_key VARCHAR(255) NULL AFTER reserved,
+    ADD UNIQUE INDEX idx_products_idempotency_key (idempotency_key);
+
+ALTER TABLE customers
+    ADD COLUMN idempotency_key VARCHAR(255) NULL AFTER phone,
+    ADD UNIQUE INDEX idx_customers_idempotency_key (idempotency_key);
+
+ALTER TABLE stock_reservations
+    ADD COLUMN idempotency_key VARCHAR(255) NULL AFTER order_id,
+    ADD UNIQUE INDEX idx_stock_reservations_idempotency_key (idempotency_key);
+
+ALTER TABLE stock_movements
+    ADD COLUMN idempotency_key VARCHAR(255) NULL AFTER note,
+    ADD UNIQUE INDEX idx_stock_movements_idempotency_key (idempotency_key);
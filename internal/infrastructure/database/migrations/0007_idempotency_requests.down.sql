@@ -0,0 +1,3 @@
+-- Elimina el almacén de idempotencia de respaldo.
+
+DROP TABLE IF EXISTS idempotency_requests;
@@ -0,0 +1,9 @@
+-- Estado de las solicitudes con Idempotency-Key mientras Redis no está disponible.
+-- Las claves vencen en expires_at, igual que la expiración de Redis.
+
+CREATE TABLE IF NOT EXISTS idempotency_requests (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    data MEDIUMBLOB NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    INDEX idx_idempotency_requests_expires_at (expires_at)
+);
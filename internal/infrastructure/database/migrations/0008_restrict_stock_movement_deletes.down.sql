@@ -0,0 +1,6 @@
+-- Vuelve a eliminar los movimientos de stock junto con su producto.
+
+ALTER TABLE stock_movements DROP FOREIGN KEY fk_stock_movements_product;
+
+ALTER TABLE stock_movements
+    ADD CONSTRAINT stock_movements_ibfk_1 FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
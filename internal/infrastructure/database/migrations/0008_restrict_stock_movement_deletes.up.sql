@@ -0,0 +1,7 @@
+-- Impide eliminar un producto con movimientos de stock, para que el historial de
+-- auditoría no se borre en cascada con el producto.
+
+ALTER TABLE stock_movements DROP FOREIGN KEY stock_movements_ibfk_1;
+
+ALTER TABLE stock_movements
+    ADD CONSTRAINT fk_stock_movements_product FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE RESTRICT;
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// baselineSchema es el esquema que creaba RunMigrations antes de las migraciones
+// versionadas.
+var baselineSchema = []string{
+	`CREATE TABLE products (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		price DECIMAL(10,2) NOT NULL,
+		stock INT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE orders (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		customer_name VARCHAR(255) NOT NULL,
+		total_amount DECIMAL(10,2) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE order_items (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		order_id INT NOT NULL,
+		product_id INT NOT NULL,
+		quantity INT NOT NULL,
+		subtotal DECIMAL(10,2) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+		FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+	)`,
+	`INSERT INTO products (name, price, stock) VALUES ('Producto A', 10.50, 100)`,
+	`INSERT INTO orders (customer_name, total_amount) VALUES ('John Doe', 21.00)`,
+	`INSERT INTO order_items (order_id, product_id, quantity, subtotal) VALUES (1, 1, 2, 21.00)`,
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("versión %d en la posición %d; las versiones deben ser consecutivas", m.Version, i)
+		}
+		if m.Down == "" {
+			t.Errorf("la migración %d_%s no tiene script down", m.Version, m.Name)
+		}
+	}
+	if migrations[0].Version != initialSchemaVersion {
+		t.Errorf("la primera migración es %d, se esperaba %d", migrations[0].Version, initialSchemaVersion)
+	}
+}
+
+// TestMigratorUpFromBaselineSchema aplica las migraciones sobre una base con el
+// esquema previo a las migraciones versionadas. Necesita una base MySQL vacía y
+// desechable en MIGRATIONS_TEST_DSN, por ejemplo
+// root:root@tcp(localhost:3306)/migrations_test?parseTime=true.
+func TestMigratorUpFromBaselineSchema(t *testing.T) {
+	dsn := os.Getenv("MIGRATIONS_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MIGRATIONS_TEST_DSN no está definido")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables > 0 {
+		t.Fatalf("la base de MIGRATIONS_TEST_DSN debe estar vacía y tiene %d tablas", tables)
+	}
+
+	for _, statement := range baselineSchema {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := migrator.Down(ctx, len(migrator.Migrations)); err != nil {
+			t.Errorf("error revirtiendo las migraciones: %v", err)
+		}
+		db.Exec("DROP TABLE IF EXISTS schema_migrations")
+	})
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("error aplicando las migraciones: %v", err)
+	}
+	if len(applied) != len(migrator.Migrations) {
+		t.Fatalf("se aplicaron %d migraciones, se esperaban %d", len(applied), len(migrator.Migrations))
+	}
+
+	// Los datos existentes toman los valores por defecto de las columnas nuevas
+	var currency string
+	var reserved int
+	if err := db.QueryRow("SELECT currency, reserved FROM products WHERE id = 1").Scan(&currency, &reserved); err != nil {
+		t.Fatal(err)
+	}
+	if currency != "USD" || reserved != 0 {
+		t.Errorf("producto con currency %q y reserved %d, se esperaba USD y 0", currency, reserved)
+	}
+
+	var status string
+	var customerID sql.NullInt64
+	if err := db.QueryRow("SELECT customer_id, currency, status FROM orders WHERE id = 1").Scan(&customerID, &currency, &status); err != nil {
+		t.Fatal(err)
+	}
+	if customerID.Valid || currency != "USD" || status != "pending" {
+		t.Errorf("orden con customer_id %v, currency %q y status %q", customerID, currency, status)
+	}
+
+	// Los items existentes toman el nombre del producto y el precio de su subtotal
+	var productName, unitPrice string
+	if err := db.QueryRow("SELECT product_name, unit_price FROM order_items WHERE id = 1").Scan(&productName, &unitPrice); err != nil {
+		t.Fatal(err)
+	}
+	if productName != "Producto A" || unitPrice != "10.50" {
+		t.Errorf("item con product_name %q y unit_price %s", productName, unitPrice)
+	}
+
+	// Una segunda ejecución no tiene migraciones pendientes
+	applied, err = migrator.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("segunda ejecución: %d migraciones aplicadas, error %v", len(applied), err)
+	}
+}
@@ -9,24 +9,21 @@ import (
 	"github.com/vizardkill/order-management/config"
 )
 
-var DB *sql.DB
-
-func InitMySQL(cfg *config.Config) {
+// OpenMySQL abre la conexión a MySQL descrita en cfg y verifica que responda.
+func OpenMySQL(cfg *config.Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
 		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
 
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Fatal("Error al conectar con MySQL:", err)
+		return nil, fmt.Errorf("error al conectar con MySQL: %w", err)
 	}
 
 	if err = db.Ping(); err != nil {
-		log.Fatal("No se pudo hacer ping a MySQL:", err)
+		db.Close()
+		return nil, fmt.Errorf("no se pudo hacer ping a MySQL: %w", err)
 	}
 
-	// Ejecutar migraciones
-	RunMigrations(db)
-
 	log.Println("Conexión a MySQL exitosa")
-	DB = db
+	return db, nil
 }
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+var _ app.CustomerRepository = (*CustomerRepository)(nil)
+
+// CustomerRepository es la implementación en memoria de app.CustomerRepository.
+type CustomerRepository struct {
+	Store *Store
+}
+
+// NewCustomerRepository crea un repositorio de clientes sobre store.
+func NewCustomerRepository(store *Store) *CustomerRepository {
+	return &CustomerRepository{Store: store}
+}
+
+// CreateCustomer inserta un cliente con sus direcciones.
+func (r *CustomerRepository) CreateCustomer(ctx context.Context, customer domain.Customer) (domain.Customer, error) {
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		if customer.IdempotencyKey != "" {
+			for _, c := range st.customers {
+				if c.IdempotencyKey == customer.IdempotencyKey {
+					return domain.ErrDuplicateIdempotencyKey
+				}
+			}
+		}
+
+		if emailTaken(st, customer.Email, 0) {
+			return domain.ErrCustomerEmailTaken
+		}
+
+		customer.ID = st.nextID("customers")
+		customer.Addresses = insertAddresses(st, customer.ID, customer.Addresses)
+		customer.CreatedAt = now()
+		customer.UpdatedAt = customer.CreatedAt
+		st.customers[customer.ID] = customer
+		return nil
+	})
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	return r.GetCustomerByID(ctx, customer.ID)
+}
+
+// GetCustomerByID obtiene un cliente por su id junto con sus direcciones.
+func (r *CustomerRepository) GetCustomerByID(ctx context.Context, id int) (domain.Customer, error) {
+	var customer domain.Customer
+	var ok bool
+	r.Store.read(func(st *state) {
+		customer, ok = st.customers[id]
+	})
+
+	if !ok {
+		return domain.Customer{}, domain.ErrCustomerNotFound.With(domain.DetailID(id))
+	}
+
+	customer.Addresses = append([]domain.Address{}, customer.Addresses...)
+	return customer, nil
+}
+
+// GetCustomerByIdempotencyKey obtiene el cliente creado con la clave de
+// idempotencia indicada.
+func (r *CustomerRepository) GetCustomerByIdempotencyKey(ctx context.Context, key string) (domain.Customer, error) {
+	id := 0
+	r.Store.read(func(st *state) {
+		for _, c := range st.customers {
+			if c.IdempotencyKey == key {
+				id = c.ID
+				return
+			}
+		}
+	})
+
+	if id == 0 {
+		return domain.Customer{}, domain.ErrCustomerNotFound
+	}
+	return r.GetCustomerByID(ctx, id)
+}
+
+// ListCustomers obtiene una página de clientes ordenados por id, opcionalmente
+// filtrados por nombre o email.
+func (r *CustomerRepository) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error) {
+	const sort = "id"
+
+	var customers []domain.Customer
+	r.Store.read(func(st *state) {
+		for _, c := range st.customers {
+			if filter.Search != "" && !containsFold(c.Name, filter.Search) && !containsFold(c.Email, filter.Search) {
+				continue
+			}
+			c.Addresses = append([]domain.Address{}, c.Addresses...)
+			customers = append(customers, c)
+		}
+	})
+
+	fields := map[string]func(a, b domain.Customer) int{
+		"id": func(a, b domain.Customer) int { return cmp.Compare(a.ID, b.ID) },
+	}
+	if err := sortItems(customers, sort, fields, customerIDOf); err != nil {
+		return domain.CustomerPage{}, err
+	}
+
+	var page domain.CustomerPage
+	var err error
+	page.Customers, page.NextCursor, err = paginate(customers, sort, filter.Limit, filter.Cursor, customerIDOf)
+	if err != nil {
+		return domain.CustomerPage{}, err
+	}
+
+	return page, nil
+}
+
+// UpdateCustomer actualiza los datos de un cliente. Los valores nil conservan el
+// valor actual; si addresses no es nil reemplaza todas las direcciones.
+func (r *CustomerRepository) UpdateCustomer(ctx context.Context, id int, data domain.UpdateCustomerService) (domain.Customer, error) {
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		customer, ok := st.customers[id]
+		if !ok {
+			return domain.ErrCustomerNotFound.With(domain.DetailID(id))
+		}
+
+		if data.Email != nil && emailTaken(st, *data.Email, id) {
+			return domain.ErrCustomerEmailTaken
+		}
+
+		if data.Name != nil {
+			customer.Name = *data.Name
+		}
+		if data.Email != nil {
+			customer.Email = *data.Email
+		}
+		if data.Phone != nil {
+			customer.Phone = *data.Phone
+		}
+		if data.Addresses != nil {
+			customer.Addresses = insertAddresses(st, id, *data.Addresses)
+		}
+		customer.UpdatedAt = now()
+		st.customers[id] = customer
+		return nil
+	})
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	return r.GetCustomerByID(ctx, id)
+}
+
+// DeleteCustomer elimina un cliente que no tenga órdenes asociadas.
+func (r *CustomerRepository) DeleteCustomer(ctx context.Context, id int) error {
+	return r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		for _, o := range st.orders {
+			if o.CustomerID != nil && *o.CustomerID == id {
+				return domain.ErrCustomerHasOrders
+			}
+		}
+
+		if _, ok := st.customers[id]; !ok {
+			return domain.ErrCustomerNotFound.With(domain.DetailID(id))
+		}
+
+		delete(st.customers, id)
+		return nil
+	})
+}
+
+// emailTaken indica si otro cliente distinto de exceptID ya usa el email. Como el
+// índice único de MySQL, la comparación no distingue mayúsculas.
+func emailTaken(st *state, email string, exceptID int) bool {
+	for _, c := range st.customers {
+		if c.ID != exceptID && strings.EqualFold(c.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertAddresses asigna ids a las direcciones de un cliente.
+func insertAddresses(st *state, customerID int, addresses []domain.Address) []domain.Address {
+	result := make([]domain.Address, len(addresses))
+	for i, a := range addresses {
+		a.ID = st.nextID("customer_addresses")
+		a.CustomerID = customerID
+		result[i] = a
+	}
+	return result
+}
+
+func customerIDOf(c domain.Customer) int { return c.ID }
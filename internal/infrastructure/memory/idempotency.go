@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+)
+
+var _ app.IdempotencyStore = (*IdempotencyStore)(nil)
+
+// IdempotencyStore es la implementación en memoria de app.IdempotencyStore.
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	data      cache.IdempotencyData
+	expiresAt time.Time
+}
+
+// NewIdempotencyStore crea un almacén de idempotencia vacío.
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{entries: map[string]idempotencyEntry{}}
+}
+
+// GetIdempotencyKey retorna cache.ErrIdempotencyKeyNotFound si la clave no existe
+// o ya expiró.
+func (s *IdempotencyStore) GetIdempotencyKey(ctx context.Context, key string) (*cache.IdempotencyData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, cache.ErrIdempotencyKeyNotFound
+	}
+
+	data := entry.data
+	return &data, nil
+}
+
+// CreateIdempotencyKey guarda data solo si la clave no existe o ya expiró.
+func (s *IdempotencyStore) CreateIdempotencyKey(ctx context.Context, key string, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && time.Now().Before(entry.expiresAt) {
+		return false, nil
+	}
+
+	s.entries[key] = idempotencyEntry{data: data, expiresAt: time.Now().Add(expiration)}
+	return true, nil
+}
+
+// ReplaceIdempotencyKey guarda data solo si el valor actual de la clave es old.
+func (s *IdempotencyStore) ReplaceIdempotencyKey(ctx context.Context, key string, old, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.holds(key, old) {
+		return false, nil
+	}
+
+	s.entries[key] = idempotencyEntry{data: data, expiresAt: time.Now().Add(expiration)}
+	return true, nil
+}
+
+// ReleaseIdempotencyKey borra la clave solo si su valor actual es old.
+func (s *IdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key string, old cache.IdempotencyData) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.holds(key, old) {
+		return false, nil
+	}
+
+	delete(s.entries, key)
+	return true, nil
+}
+
+// holds indica si la clave existe, no expiró y su valor es data. Los valores se
+// comparan serializados, igual que en Redis.
+func (s *IdempotencyStore) holds(key string, data cache.IdempotencyData) bool {
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return false
+	}
+
+	current, err := json.Marshal(entry.data)
+	if err != nil {
+		return false
+	}
+	expected, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(current, expected)
+}
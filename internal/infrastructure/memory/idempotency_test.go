@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+)
+
+func TestIdempotencyStoreLeases(t *testing.T) {
+	ctx := context.Background()
+	store := NewIdempotencyStore()
+
+	lease := cache.IdempotencyData{Status: "IN_PROGRESS", Fingerprint: "a", LeaseExpiresAt: time.Now().Add(time.Minute).UTC()}
+	if ok, err := store.CreateIdempotencyKey(ctx, "k", lease, time.Minute); err != nil || !ok {
+		t.Fatalf("crear: %v, %v", ok, err)
+	}
+	if ok, _ := store.CreateIdempotencyKey(ctx, "k", lease, time.Minute); ok {
+		t.Error("se creó una clave que ya existía")
+	}
+
+	// Solo quien conoce el valor actual puede reemplazarlo o liberarlo
+	stale := lease
+	stale.Fingerprint = "b"
+	if ok, _ := store.ReplaceIdempotencyKey(ctx, "k", stale, stale, time.Minute); ok {
+		t.Error("se reemplazó la clave con un valor anterior distinto")
+	}
+	if ok, _ := store.ReleaseIdempotencyKey(ctx, "k", stale); ok {
+		t.Error("se liberó la clave con un valor anterior distinto")
+	}
+
+	completed := cache.IdempotencyData{Status: "COMPLETED", Fingerprint: "a", StatusCode: 201, Body: []byte(`{}`)}
+	if ok, err := store.ReplaceIdempotencyKey(ctx, "k", lease, completed, 20*time.Millisecond); err != nil || !ok {
+		t.Fatalf("completar: %v, %v", ok, err)
+	}
+	got, err := store.GetIdempotencyKey(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "COMPLETED" || got.StatusCode != 201 {
+		t.Errorf("clave %+v, se esperaba la respuesta completada", got)
+	}
+
+	// Las claves expiran igual que en Redis
+	time.Sleep(30 * time.Millisecond)
+	if _, err := store.GetIdempotencyKey(ctx, "k"); !errors.Is(err, cache.ErrIdempotencyKeyNotFound) {
+		t.Errorf("clave vencida: error %v, se esperaba ErrIdempotencyKeyNotFound", err)
+	}
+	if ok, _ := store.CreateIdempotencyKey(ctx, "k", lease, time.Minute); !ok {
+		t.Error("no se pudo crear de nuevo una clave vencida")
+	}
+}
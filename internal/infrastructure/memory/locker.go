@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+var _ app.Locker = (*Locker)(nil)
+
+// Locker es la implementación en memoria de app.Locker. Los locks expiran igual
+// que las claves con TTL de Redis y solo su propietario puede extenderlos o
+// liberarlos.
+type Locker struct {
+	mu     sync.Mutex
+	locks  map[string]heldLock
+	tokens int
+}
+
+type heldLock struct {
+	token     int
+	expiresAt time.Time
+}
+
+// NewLocker crea un Locker sin locks tomados.
+func NewLocker() *Locker {
+	return &Locker{locks: map[string]heldLock{}}
+}
+
+// TryLock toma el lock si está libre o si su expiración ya pasó.
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if held, ok := l.locks[key]; ok && time.Now().Before(held.expiresAt) {
+		return nil, domain.ErrLockNotAcquired
+	}
+
+	l.tokens++
+	l.locks[key] = heldLock{token: l.tokens, expiresAt: time.Now().Add(ttl)}
+	return &lock{locker: l, key: key, token: l.tokens}, nil
+}
+
+// lock es un lock tomado en el Locker en memoria.
+type lock struct {
+	locker *Locker
+	key    string
+	token  int
+}
+
+func (k *lock) Key() string {
+	return k.key
+}
+
+func (k *lock) Extend(ctx context.Context, ttl time.Duration) error {
+	k.locker.mu.Lock()
+	defer k.locker.mu.Unlock()
+
+	if !k.held() {
+		return domain.ErrLockNotHeld
+	}
+
+	k.locker.locks[k.key] = heldLock{token: k.token, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (k *lock) Release(ctx context.Context) error {
+	k.locker.mu.Lock()
+	defer k.locker.mu.Unlock()
+
+	if !k.held() {
+		return domain.ErrLockNotHeld
+	}
+
+	delete(k.locker.locks, k.key)
+	return nil
+}
+
+// held indica si el lock sigue vigente y pertenece a este token. Debe llamarse
+// con el mutex del Locker tomado.
+func (k *lock) held() bool {
+	held, ok := k.locker.locks[k.key]
+	return ok && held.token == k.token && time.Now().Before(held.expiresAt)
+}
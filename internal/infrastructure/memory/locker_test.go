@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestLockerExpiresLocks(t *testing.T) {
+	ctx := context.Background()
+	locker := NewLocker()
+
+	first, err := locker.TryLock(ctx, "product:1", 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.TryLock(ctx, "product:1", time.Second); !errors.Is(err, domain.ErrLockNotAcquired) {
+		t.Fatalf("lock tomado: error %v, se esperaba ErrLockNotAcquired", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Vencido el TTL otro propietario puede tomarlo y el anterior ya no lo controla
+	second, err := locker.TryLock(ctx, "product:1", time.Second)
+	if err != nil {
+		t.Fatalf("lock vencido: %v", err)
+	}
+	if err := first.Release(ctx); !errors.Is(err, domain.ErrLockNotHeld) {
+		t.Errorf("liberar un lock vencido: error %v, se esperaba ErrLockNotHeld", err)
+	}
+	if err := first.Extend(ctx, time.Second); !errors.Is(err, domain.ErrLockNotHeld) {
+		t.Errorf("extender un lock vencido: error %v, se esperaba ErrLockNotHeld", err)
+	}
+
+	if err := second.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.TryLock(ctx, "product:1", time.Second); err != nil {
+		t.Errorf("lock liberado: %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+var _ app.OrderRepository = (*OrderRepository)(nil)
+
+// OrderRepository es la implementación en memoria de app.OrderRepository.
+type OrderRepository struct {
+	Store *Store
+}
+
+// NewOrderRepository crea un repositorio de órdenes sobre store.
+func NewOrderRepository(store *Store) *OrderRepository {
+	return &OrderRepository{Store: store}
+}
+
+// CreateOrder inserta una orden con sus items e invoca reduceStockFunc con el id de
+// la orden dentro de la misma transacción.
+func (r *OrderRepository) CreateOrder(ctx context.Context, order domain.Order, reduceStockFunc func(tx domain.Tx, orderID int) error) (domain.Order, error) {
+	tx := r.Store.Begin()
+	st := tx.state()
+
+	if order.IdempotencyKey != "" {
+		for _, o := range st.orders {
+			if o.IdempotencyKey == order.IdempotencyKey {
+				tx.Rollback()
+				return domain.Order{}, domain.ErrDuplicateIdempotencyKey
+			}
+		}
+	}
+
+	order.ID = st.nextID("orders")
+	order.Status = domain.OrderStatusPending
+	order.CreatedAt = now()
+	order.UpdatedAt = order.CreatedAt
+
+	items := make([]domain.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		item.ID = st.nextID("order_items")
+		item.OrderID = order.ID
+		items[i] = item
+	}
+	order.Items = items
+	st.orders[order.ID] = order
+
+	// Reducir el stock de los productos
+	if err := reduceStockFunc(tx, order.ID); err != nil {
+		tx.Rollback()
+		return domain.Order{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Order{}, err
+	}
+
+	return r.GetOrderWithItemsByID(ctx, order.ID)
+}
+
+// GetOrderWithItemsByID obtiene una orden por su id con sus items.
+func (r *OrderRepository) GetOrderWithItemsByID(ctx context.Context, id int) (domain.Order, error) {
+	var order domain.Order
+	var ok bool
+	r.Store.read(func(st *state) {
+		order, ok = st.orders[id]
+	})
+
+	if !ok {
+		return domain.Order{}, domain.ErrOrderNotFound.With(domain.DetailID(id))
+	}
+
+	order.Items = append([]domain.OrderItem{}, order.Items...)
+	return order, nil
+}
+
+// GetOrderByIdempotencyKey obtiene la orden creada con la clave de idempotencia
+// indicada.
+func (r *OrderRepository) GetOrderByIdempotencyKey(ctx context.Context, key string) (domain.Order, error) {
+	id := 0
+	r.Store.read(func(st *state) {
+		for _, o := range st.orders {
+			if o.IdempotencyKey == key {
+				id = o.ID
+				return
+			}
+		}
+	})
+
+	if id == 0 {
+		return domain.Order{}, domain.ErrOrderNotFound
+	}
+	return r.GetOrderWithItemsByID(ctx, id)
+}
+
+// TransitionOrderStatus cambia el estado de una orden validando la tabla de
+// transiciones y registra el cambio.
+func (r *OrderRepository) TransitionOrderStatus(ctx context.Context, orderID int, status domain.OrderStatus) (domain.OrderStatusTransition, error) {
+	var transition domain.OrderStatusTransition
+
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		order, ok := st.orders[orderID]
+		if !ok {
+			return domain.ErrOrderNotFound.With(domain.DetailID(orderID))
+		}
+
+		if !order.Status.CanTransitionTo(status) {
+			return domain.ErrInvalidOrderStatusTransition.With(domain.DetailTransition(order.Status, status))
+		}
+
+		transition = recordStatusTransition(st, orderID, order.Status, status)
+		return nil
+	})
+
+	return transition, err
+}
+
+// CancelOrder marca una orden como cancelada e invoca restoreStockFunc con sus
+// items dentro de la misma transacción.
+func (r *OrderRepository) CancelOrder(ctx context.Context, orderID int, restoreStockFunc func(tx domain.Tx, items []domain.OrderItem) error) (domain.OrderStatusTransition, error) {
+	var transition domain.OrderStatusTransition
+
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		order, ok := st.orders[orderID]
+		if !ok {
+			return domain.ErrOrderNotFound.With(domain.DetailID(orderID))
+		}
+
+		// Las órdenes enviadas, entregadas o ya canceladas no pueden cancelarse
+		if !order.Status.CanTransitionTo(domain.OrderStatusCancelled) {
+			return domain.ErrInvalidOrderStatusTransition.With(domain.DetailTransition(order.Status, domain.OrderStatusCancelled))
+		}
+
+		// Devolver el stock de los productos
+		if err := restoreStockFunc(tx, append([]domain.OrderItem(nil), order.Items...)); err != nil {
+			return err
+		}
+
+		transition = recordStatusTransition(st, orderID, order.Status, domain.OrderStatusCancelled)
+		return nil
+	})
+
+	return transition, err
+}
+
+// AmendOrder verifica que la orden aún no haya sido enviada e invoca amendFunc con
+// sus items actuales. Dentro de la misma transacción reemplaza los items por los
+// que retorna amendFunc, actualiza el total y registra la modificación.
+func (r *OrderRepository) AmendOrder(ctx context.Context, orderID int, actor string, amendFunc func(tx domain.Tx, order domain.Order) (domain.Order, error)) (domain.OrderAmendment, error) {
+	var amendment domain.OrderAmendment
+
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		order, ok := st.orders[orderID]
+		if !ok {
+			return domain.ErrOrderNotFound.With(domain.DetailID(orderID))
+		}
+
+		if !order.Status.IsAmendable() {
+			return domain.ErrOrderNotAmendable.With(domain.DetailStatus(order.Status))
+		}
+
+		// Ajustar el stock y calcular los nuevos items
+		current := order
+		current.Items = append([]domain.OrderItem(nil), order.Items...)
+		amended, err := amendFunc(tx, current)
+		if err != nil {
+			return err
+		}
+
+		amendment = domain.OrderAmendment{
+			OrderID:       orderID,
+			Actor:         actor,
+			PreviousTotal: order.TotalAmount,
+			NewTotal:      order.TotalAmount,
+			Changes:       domain.DiffOrderItems(order.Items, amended.Items),
+		}
+		if len(amendment.Changes) == 0 {
+			return nil
+		}
+
+		// Conservar el id de los items que siguen en la orden
+		ids := make(map[int]int, len(order.Items))
+		for _, item := range order.Items {
+			ids[item.ProductID] = item.ID
+		}
+		items := make([]domain.OrderItem, len(amended.Items))
+		for i, item := range amended.Items {
+			item.ID, ok = ids[item.ProductID]
+			if !ok {
+				item.ID = st.nextID("order_items")
+			}
+			item.OrderID = orderID
+			items[i] = item
+		}
+
+		order.Items = items
+		order.TotalAmount = amended.TotalAmount
+		order.UpdatedAt = now()
+		st.orders[orderID] = order
+
+		// Registrar la modificación en el historial
+		amendment.ID = st.nextID("order_amendments")
+		amendment.NewTotal = amended.TotalAmount
+		amendment.CreatedAt = now()
+		st.amendments = append(st.amendments, amendment)
+		return nil
+	})
+
+	if err != nil {
+		return domain.OrderAmendment{}, err
+	}
+	return amendment, nil
+}
+
+// recordStatusTransition actualiza el estado de la orden y guarda el registro de
+// la transición.
+func recordStatusTransition(st *state, orderID int, from, to domain.OrderStatus) domain.OrderStatusTransition {
+	order := st.orders[orderID]
+	order.Status = to
+	order.UpdatedAt = now()
+	st.orders[orderID] = order
+
+	transition := domain.OrderStatusTransition{
+		ID:         st.nextID("order_status_transitions"),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		CreatedAt:  now(),
+	}
+	st.transitions = append(st.transitions, transition)
+
+	return transition
+}
+
+// orderSortFields son los campos por los que se permite ordenar el listado de órdenes.
+var orderSortFields = map[string]func(a, b domain.Order) int{
+	"id":           func(a, b domain.Order) int { return cmp.Compare(a.ID, b.ID) },
+	"created_at":   func(a, b domain.Order) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"total_amount": func(a, b domain.Order) int { return cmp.Compare(a.TotalAmount.Amount, b.TotalAmount.Amount) },
+}
+
+// ListOrders retorna una página de órdenes que cumplen el filtro. Los items solo
+// se incluyen cuando el filtro lo solicita.
+func (r *OrderRepository) ListOrders(ctx context.Context, filter domain.OrderFilter) (domain.OrderPage, error) {
+	var orders []domain.Order
+	r.Store.read(func(st *state) {
+		for _, o := range st.orders {
+			if filter.CustomerID != 0 && (o.CustomerID == nil || *o.CustomerID != filter.CustomerID) {
+				continue
+			}
+			if filter.CustomerName != "" && !containsFold(o.CustomerName, filter.CustomerName) {
+				continue
+			}
+			if filter.CreatedFrom != nil && o.CreatedAt.Before(*filter.CreatedFrom) {
+				continue
+			}
+			if filter.CreatedTo != nil && o.CreatedAt.After(*filter.CreatedTo) {
+				continue
+			}
+			if filter.MinTotal != nil && o.TotalAmount.Amount < filter.MinTotal.Amount {
+				continue
+			}
+			if filter.MaxTotal != nil && o.TotalAmount.Amount > filter.MaxTotal.Amount {
+				continue
+			}
+			if filter.Status != "" && o.Status != filter.Status {
+				continue
+			}
+
+			if filter.ExpandItems {
+				o.Items = append([]domain.OrderItem{}, o.Items...)
+			} else {
+				o.Items = nil
+			}
+			orders = append(orders, o)
+		}
+	})
+
+	if err := sortItems(orders, filter.Sort, orderSortFields, orderIDOf); err != nil {
+		return domain.OrderPage{}, err
+	}
+
+	var page domain.OrderPage
+	var err error
+	page.Orders, page.NextCursor, err = paginate(orders, filter.Sort, filter.Limit, filter.Cursor, orderIDOf)
+	if err != nil {
+		return domain.OrderPage{}, err
+	}
+
+	return page, nil
+}
+
+func orderIDOf(o domain.Order) int { return o.ID }
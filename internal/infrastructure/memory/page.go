@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// pageCursor identifica el último elemento devuelto de un listado ordenado.
+type pageCursor struct {
+	Sort string `json:"s"`
+	ID   int    `json:"id"`
+}
+
+func encodeCursor(sort string, id int) string {
+	data, _ := json.Marshal(pageCursor{Sort: sort, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor, sort string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, domain.ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort {
+		return 0, domain.ErrInvalidCursor
+	}
+
+	return c.ID, nil
+}
+
+// sortItems ordena items por el campo indicado en sort ("campo" o "-campo"),
+// desempatando por id en la misma dirección, igual que los listados de MySQL.
+func sortItems[T any](items []T, sort string, fields map[string]func(a, b T) int, id func(T) int) error {
+	field, desc := strings.CutPrefix(sort, "-")
+	compare, ok := fields[field]
+	if !ok {
+		return fmt.Errorf("campo de ordenamiento no soportado: %s", field)
+	}
+
+	slices.SortStableFunc(items, func(a, b T) int {
+		c := compare(a, b)
+		if c == 0 {
+			c = cmp.Compare(id(a), id(b))
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+	return nil
+}
+
+// paginate retorna hasta limit elementos posteriores al cursor de una lista ya
+// filtrada y ordenada, junto con el cursor de la página siguiente.
+func paginate[T any](items []T, sort string, limit int, cursor string, id func(T) int) ([]T, string, error) {
+	if cursor != "" {
+		last, err := decodeCursor(cursor, sort)
+		if err != nil {
+			return nil, "", err
+		}
+
+		i := slices.IndexFunc(items, func(item T) bool { return id(item) == last })
+		if i < 0 {
+			return nil, "", domain.ErrInvalidCursor
+		}
+		items = items[i+1:]
+	}
+
+	next := ""
+	if len(items) > limit {
+		items = items[:limit]
+		next = encodeCursor(sort, id(items[len(items)-1]))
+	}
+
+	return append([]T{}, items...), next, nil
+}
+
+// containsFold indica si s contiene substr sin distinguir mayúsculas, como LIKE
+// con la collation por defecto de MySQL.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
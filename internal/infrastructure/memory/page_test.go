@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestPaginateWalksAllPages(t *testing.T) {
+	items := []int{5, 3, 9, 1, 7}
+	identity := func(id int) int { return id }
+
+	var got []int
+	cursor := ""
+	for range len(items) {
+		page, next, err := paginate(items, "-id", 2, cursor, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if !slices.Equal(got, items) {
+		t.Errorf("elementos %v, se esperaba %v", got, items)
+	}
+}
+
+func TestPaginateRejectsInvalidCursors(t *testing.T) {
+	items := []int{1, 2, 3}
+	identity := func(id int) int { return id }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"otro ordenamiento", encodeCursor("-id", 1)},
+		{"alterado", "e30x"},
+		{"no es base64", "%%%"},
+		{"elemento inexistente", encodeCursor("id", 99)},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := paginate(items, "id", 2, tt.cursor, identity); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("%s: error %v, se esperaba %v", tt.name, err, domain.ErrInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+var _ app.ProductRepository = (*ProductRepository)(nil)
+
+// ProductRepository es la implementación en memoria de app.ProductRepository.
+type ProductRepository struct {
+	Store *Store
+}
+
+// NewProductRepository crea un repositorio de productos sobre store.
+func NewProductRepository(store *Store) *ProductRepository {
+	return &ProductRepository{Store: store}
+}
+
+// productSortFields son los campos por los que se permite ordenar el listado de productos.
+var productSortFields = map[string]func(a, b domain.Product) int{
+	"id":         func(a, b domain.Product) int { return cmp.Compare(a.ID, b.ID) },
+	"name":       func(a, b domain.Product) int { return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"price":      func(a, b domain.Product) int { return cmp.Compare(a.Price.Amount, b.Price.Amount) },
+	"stock":      func(a, b domain.Product) int { return cmp.Compare(a.Stock, b.Stock) },
+	"updated_at": func(a, b domain.Product) int { return cmp.Compare(a.UpdatedAt, b.UpdatedAt) },
+}
+
+// ListProducts obtiene una página de productos que cumplen el filtro junto con el
+// total de productos que lo cumplen.
+func (r *ProductRepository) ListProducts(ctx context.Context, filter domain.ProductFilter) (domain.ProductPage, error) {
+	var products []domain.Product
+	r.Store.read(func(st *state) {
+		for _, p := range st.products {
+			if filter.Search != "" && !containsFold(p.Name, filter.Search) {
+				continue
+			}
+			if filter.MinPrice != nil && p.Price.Amount < filter.MinPrice.Amount {
+				continue
+			}
+			if filter.MaxPrice != nil && p.Price.Amount > filter.MaxPrice.Amount {
+				continue
+			}
+			if filter.InStock != nil && (p.Available() > 0) != *filter.InStock {
+				continue
+			}
+			products = append(products, p)
+		}
+	})
+
+	if err := sortItems(products, filter.Sort, productSortFields, productIDOf); err != nil {
+		return domain.ProductPage{}, err
+	}
+
+	page := domain.ProductPage{Total: len(products)}
+	var err error
+	page.Products, page.NextCursor, err = paginate(products, filter.Sort, filter.Limit, filter.Cursor, productIDOf)
+	if err != nil {
+		return domain.ProductPage{}, err
+	}
+
+	return page, nil
+}
+
+// GetProductByID obtiene un producto por su id.
+func (r *ProductRepository) GetProductByID(ctx context.Context, id int) (domain.Product, error) {
+	var p domain.Product
+	var ok bool
+	r.Store.read(func(st *state) {
+		p, ok = st.products[id]
+	})
+
+	if !ok {
+		return domain.Product{}, domain.ErrProductNotFound.With(domain.DetailID(id))
+	}
+	return p, nil
+}
+
+// GetProductByIdempotencyKey obtiene el producto creado con la clave de
+// idempotencia indicada.
+func (r *ProductRepository) GetProductByIdempotencyKey(ctx context.Context, key string) (domain.Product, error) {
+	id := 0
+	r.Store.read(func(st *state) {
+		for _, p := range st.products {
+			if p.IdempotencyKey == key {
+				id = p.ID
+				return
+			}
+		}
+	})
+
+	if id == 0 {
+		return domain.Product{}, domain.ErrProductNotFound
+	}
+	return r.GetProductByID(ctx, id)
+}
+
+// ReduceStockWithTransaction descuenta stock disponible dentro de la transacción.
+func (r *ProductRepository) ReduceStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int, movement domain.StockMovement) error {
+	st := memoryTx(t).state()
+
+	p, ok := st.products[productID]
+	if !ok || p.Available() < quantity {
+		return domain.ErrInsufficientStock.With(domain.DetailProductUnavailable(productID))
+	}
+
+	p.Stock -= quantity
+	st.products[productID] = p
+
+	_, err := recordStockMovement(st, productID, -quantity, movement)
+	return err
+}
+
+// IncreaseStockWithTransaction incrementa el stock dentro de la transacción.
+func (r *ProductRepository) IncreaseStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int, movement domain.StockMovement) error {
+	st := memoryTx(t).state()
+
+	p, ok := st.products[productID]
+	if !ok {
+		return domain.ErrProductNotFound.With(domain.DetailID(productID))
+	}
+
+	p.Stock += quantity
+	st.products[productID] = p
+
+	_, err := recordStockMovement(st, productID, quantity, movement)
+	return err
+}
+
+// ReserveStockWithTransaction retiene stock disponible dentro de la transacción.
+func (r *ProductRepository) ReserveStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int) error {
+	st := memoryTx(t).state()
+
+	p, ok := st.products[productID]
+	if !ok || p.Available() < quantity {
+		return domain.ErrInsufficientStock.With(domain.DetailProductUnavailable(productID))
+	}
+
+	p.Reserved += quantity
+	st.products[productID] = p
+	return nil
+}
+
+// ReleaseReservedStockWithTransaction devuelve stock retenido dentro de la transacción.
+func (r *ProductRepository) ReleaseReservedStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int) error {
+	st := memoryTx(t).state()
+
+	p, ok := st.products[productID]
+	if !ok || p.Reserved < quantity {
+		return errors.New("no se pudo liberar el stock reservado o producto no encontrado")
+	}
+
+	p.Reserved -= quantity
+	st.products[productID] = p
+	return nil
+}
+
+// ConsumeReservedStockWithTransaction convierte stock reservado en vendido dentro
+// de la transacción.
+func (r *ProductRepository) ConsumeReservedStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int, movement domain.StockMovement) error {
+	st := memoryTx(t).state()
+
+	p, ok := st.products[productID]
+	if !ok || p.Reserved < quantity || p.Stock < quantity {
+		return errors.New("no se pudo consumir el stock reservado o producto no encontrado")
+	}
+
+	p.Stock -= quantity
+	p.Reserved -= quantity
+	st.products[productID] = p
+
+	_, err := recordStockMovement(st, productID, -quantity, movement)
+	return err
+}
+
+// LockProductsWithTransaction no necesita hacer nada: la transacción en memoria
+// ya tiene el almacenamiento completo bloqueado.
+func (r *ProductRepository) LockProductsWithTransaction(ctx context.Context, t domain.Tx, productIDs []int) error {
+	return nil
+}
+
+// UpdateStock reemplaza el stock de un producto sin bajar de lo reservado.
+func (r *ProductRepository) UpdateStock(ctx context.Context, productID int, quantity int, movement domain.StockMovement) error {
+	return r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		p, ok := st.products[productID]
+		if !ok {
+			return domain.ErrProductNotFound.With(domain.DetailID(productID))
+		}
+
+		if quantity < p.Reserved {
+			return domain.ErrInsufficientStock.With(domain.DetailBelowReserved(p.Reserved))
+		}
+
+		// Sin cambios no hay movimiento que registrar
+		if quantity == p.Stock {
+			return nil
+		}
+
+		delta := quantity - p.Stock
+		p.Stock = quantity
+		st.products[productID] = p
+
+		_, err := recordStockMovement(st, productID, delta, movement)
+		return err
+	})
+}
+
+// AdjustStock suma delta al stock de un producto sin bajar de lo reservado.
+func (r *ProductRepository) AdjustStock(ctx context.Context, productID int, delta int, movement domain.StockMovement) (domain.StockMovement, error) {
+	var applied domain.StockMovement
+
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		if movement.IdempotencyKey != "" {
+			for _, m := range st.movements {
+				if m.IdempotencyKey == movement.IdempotencyKey {
+					return domain.ErrDuplicateIdempotencyKey
+				}
+			}
+		}
+
+		p, ok := st.products[productID]
+		if !ok {
+			return domain.ErrProductNotFound.With(domain.DetailID(productID))
+		}
+
+		if p.Stock+delta < p.Reserved {
+			return domain.ErrInsufficientStock.With(domain.DetailStockAdjustment(delta, productID))
+		}
+
+		p.Stock += delta
+		st.products[productID] = p
+
+		var err error
+		applied, err = recordStockMovement(st, productID, delta, movement)
+		return err
+	})
+
+	return applied, err
+}
+
+// GetStockMovementByIdempotencyKey obtiene el movimiento registrado con la clave
+// de idempotencia indicada.
+func (r *ProductRepository) GetStockMovementByIdempotencyKey(ctx context.Context, key string) (domain.StockMovement, error) {
+	var movement domain.StockMovement
+	var ok bool
+	r.Store.read(func(st *state) {
+		for _, m := range st.movements {
+			if m.IdempotencyKey == key {
+				movement, ok = m, true
+				return
+			}
+		}
+	})
+
+	if !ok {
+		return domain.StockMovement{}, domain.ErrStockMovementNotFound
+	}
+	return movement, nil
+}
+
+// CreateProduct inserta un producto y registra su stock inicial.
+func (r *ProductRepository) CreateProduct(ctx context.Context, product domain.Product, movement domain.StockMovement) (domain.Product, error) {
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		if product.IdempotencyKey != "" {
+			for _, p := range st.products {
+				if p.IdempotencyKey == product.IdempotencyKey {
+					return domain.ErrDuplicateIdempotencyKey
+				}
+			}
+		}
+
+		timestamp := now().Format(time.RFC3339Nano)
+		product.ID = st.nextID("products")
+		product.Reserved = 0
+		product.CreatedAt = timestamp
+		product.UpdatedAt = timestamp
+		st.products[product.ID] = product
+
+		if product.Stock > 0 {
+			if _, err := recordStockMovement(st, product.ID, product.Stock, movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return r.GetProductByID(ctx, product.ID)
+}
+
+// UpdateProduct actualiza el nombre y/o el precio de un producto.
+func (r *ProductRepository) UpdateProduct(ctx context.Context, productID int, name *string, price *domain.Money) (domain.Product, error) {
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		p, ok := st.products[productID]
+		if !ok {
+			return domain.ErrProductNotFound.With(domain.DetailID(productID))
+		}
+
+		if name != nil {
+			p.Name = *name
+		}
+		if price != nil {
+			p.Price.Amount = price.Amount
+			if price.Currency != "" {
+				p.Price.Currency = price.Currency
+			}
+		}
+		p.UpdatedAt = now().Format(time.RFC3339Nano)
+		st.products[productID] = p
+		return nil
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return r.GetProductByID(ctx, productID)
+}
+
+// DeleteProduct elimina un producto que no haya sido incluido en ninguna orden.
+func (r *ProductRepository) DeleteProduct(ctx context.Context, productID int) error {
+	return r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		for _, o := range st.orders {
+			for _, item := range o.Items {
+				if item.ProductID == productID {
+					return domain.ErrProductHasOrders
+				}
+			}
+		}
+
+		// Igual que ON DELETE RESTRICT sobre stock_movements
+		for _, m := range st.movements {
+			if m.ProductID == productID {
+				return domain.ErrProductHasStockMovements
+			}
+		}
+
+		if _, ok := st.products[productID]; !ok {
+			return domain.ErrProductNotFound.With(domain.DetailID(productID))
+		}
+		delete(st.products, productID)
+		return nil
+	})
+}
+
+// ListStockMovements obtiene el historial de stock de un producto, del movimiento
+// más reciente al más antiguo.
+func (r *ProductRepository) ListStockMovements(ctx context.Context, productID int, limit int, cursor string) (domain.StockMovementPage, error) {
+	const sort = "-id"
+
+	var movements []domain.StockMovement
+	r.Store.read(func(st *state) {
+		for _, m := range st.movements {
+			if m.ProductID == productID {
+				movements = append(movements, m)
+			}
+		}
+	})
+
+	fields := map[string]func(a, b domain.StockMovement) int{
+		"id": func(a, b domain.StockMovement) int { return cmp.Compare(a.ID, b.ID) },
+	}
+	if err := sortItems(movements, sort, fields, movementIDOf); err != nil {
+		return domain.StockMovementPage{}, err
+	}
+
+	var page domain.StockMovementPage
+	var err error
+	page.Movements, page.NextCursor, err = paginate(movements, sort, limit, cursor, movementIDOf)
+	if err != nil {
+		return domain.StockMovementPage{}, err
+	}
+
+	return page, nil
+}
+
+// recordStockMovement registra un cambio de stock ya aplicado junto con el saldo
+// resultante del producto.
+func recordStockMovement(st *state, productID int, delta int, movement domain.StockMovement) (domain.StockMovement, error) {
+	p, ok := st.products[productID]
+	if !ok {
+		return domain.StockMovement{}, domain.ErrProductNotFound.With(domain.DetailID(productID))
+	}
+
+	if movement.Actor == "" {
+		movement.Actor = domain.AnonymousActor
+	}
+	movement.ID = st.nextID("stock_movements")
+	movement.ProductID = productID
+	movement.Delta = delta
+	movement.Balance = p.Stock
+	movement.CreatedAt = now()
+
+	st.movements = append(st.movements, movement)
+	return movement, nil
+}
+
+func productIDOf(p domain.Product) int { return p.ID }
+
+func movementIDOf(m domain.StockMovement) int { return m.ID }
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+var _ app.ReservationRepository = (*ReservationRepository)(nil)
+
+// ReservationRepository es la implementación en memoria de app.ReservationRepository.
+type ReservationRepository struct {
+	Store *Store
+}
+
+// NewReservationRepository crea un repositorio de reservas sobre store.
+func NewReservationRepository(store *Store) *ReservationRepository {
+	return &ReservationRepository{Store: store}
+}
+
+// CreateReservation inserta una reserva activa con sus items e invoca
+// reserveStockFunc dentro de la misma transacción.
+func (r *ReservationRepository) CreateReservation(ctx context.Context, reservation domain.Reservation, reserveStockFunc func(tx domain.Tx) error) (domain.Reservation, error) {
+	tx := r.Store.Begin()
+	st := tx.state()
+
+	if reservation.IdempotencyKey != "" {
+		for _, res := range st.reservations {
+			if res.IdempotencyKey == reservation.IdempotencyKey {
+				tx.Rollback()
+				return domain.Reservation{}, domain.ErrDuplicateIdempotencyKey
+			}
+		}
+	}
+
+	reservation.ID = st.nextID("stock_reservations")
+	reservation.Status = domain.ReservationStatusActive
+	reservation.OrderID = nil
+	reservation.CreatedAt = now()
+	reservation.UpdatedAt = reservation.CreatedAt
+
+	items := make([]domain.ReservationItem, len(reservation.Items))
+	for i, item := range reservation.Items {
+		item.ID = st.nextID("stock_reservation_items")
+		item.ReservationID = reservation.ID
+		items[i] = item
+	}
+	reservation.Items = items
+	st.reservations[reservation.ID] = reservation
+
+	// Retener el stock de los productos
+	if err := reserveStockFunc(tx); err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return r.GetReservationByID(ctx, reservation.ID)
+}
+
+// GetReservationByID obtiene una reserva por su id junto con sus items.
+func (r *ReservationRepository) GetReservationByID(ctx context.Context, id int) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	var ok bool
+	r.Store.read(func(st *state) {
+		reservation, ok = st.reservations[id]
+	})
+
+	if !ok {
+		return domain.Reservation{}, domain.ErrReservationNotFound.With(domain.DetailID(id))
+	}
+
+	reservation.Items = append([]domain.ReservationItem{}, reservation.Items...)
+	return reservation, nil
+}
+
+// GetReservationByIdempotencyKey obtiene la reserva creada con la clave de
+// idempotencia indicada.
+func (r *ReservationRepository) GetReservationByIdempotencyKey(ctx context.Context, key string) (domain.Reservation, error) {
+	id := 0
+	r.Store.read(func(st *state) {
+		for _, res := range st.reservations {
+			if res.IdempotencyKey == key {
+				id = res.ID
+				return
+			}
+		}
+	})
+
+	if id == 0 {
+		return domain.Reservation{}, domain.ErrReservationNotFound
+	}
+	return r.GetReservationByID(ctx, id)
+}
+
+// ReleaseReservation cierra una reserva activa con el estado indicado e invoca
+// releaseStockFunc con sus items dentro de la misma transacción.
+func (r *ReservationRepository) ReleaseReservation(ctx context.Context, id int, status domain.ReservationStatus, releaseStockFunc func(tx domain.Tx, items []domain.ReservationItem) error) (domain.Reservation, error) {
+	var reservation domain.Reservation
+
+	err := r.Store.write(func(tx *Tx) error {
+		st := tx.state()
+
+		var ok bool
+		reservation, ok = st.reservations[id]
+		if !ok {
+			return domain.ErrReservationNotFound.With(domain.DetailID(id))
+		}
+
+		if reservation.Status != domain.ReservationStatusActive {
+			return domain.ErrReservationNotActive.With(domain.DetailStatus(reservation.Status))
+		}
+
+		// Devolver el stock retenido
+		reservation.Items = append([]domain.ReservationItem{}, reservation.Items...)
+		if err := releaseStockFunc(tx, reservation.Items); err != nil {
+			return err
+		}
+
+		reservation.Status = status
+		reservation.UpdatedAt = now()
+		st.reservations[id] = reservation
+		return nil
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return reservation, nil
+}
+
+// ConsumeReservationWithTransaction marca una reserva como confirmada por la orden
+// indicada verificando que siga activa y sin expirar en el instante now.
+func (r *ReservationRepository) ConsumeReservationWithTransaction(ctx context.Context, t domain.Tx, id int, orderID int, now time.Time) (domain.Reservation, error) {
+	st := memoryTx(t).state()
+
+	reservation, ok := st.reservations[id]
+	if !ok {
+		return domain.Reservation{}, domain.ErrReservationNotFound.With(domain.DetailID(id))
+	}
+
+	if !reservation.IsActiveAt(now) {
+		return domain.Reservation{}, domain.ErrReservationNotActive.With(domain.DetailReservation(id))
+	}
+
+	reservation.Status = domain.ReservationStatusConfirmed
+	reservation.OrderID = &orderID
+	reservation.Items = append([]domain.ReservationItem{}, reservation.Items...)
+	st.reservations[id] = reservation
+
+	return reservation, nil
+}
+
+// ListExpiredReservationIDs retorna hasta limit reservas activas cuyo vencimiento
+// ya pasó en el instante now, de la más antigua a la más reciente.
+func (r *ReservationRepository) ListExpiredReservationIDs(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	var expired []domain.Reservation
+	r.Store.read(func(st *state) {
+		for _, res := range st.reservations {
+			if res.Status == domain.ReservationStatusActive && !res.ExpiresAt.After(now) {
+				expired = append(expired, res)
+			}
+		}
+	})
+
+	slices.SortFunc(expired, func(a, b domain.Reservation) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	var ids []int
+	for _, res := range expired {
+		ids = append(ids, res.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,154 @@
+// Package memory implementa los repositorios, el locker y el almacén de
+// idempotencia en memoria. Está pensado para probar la capa de servicios sin
+// MySQL ni Redis, conservando la semántica transaccional de las implementaciones
+// reales: los cambios hechos dentro de una transacción solo se confirman con
+// Commit y se descartan por completo con Rollback.
+package memory
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// Store contiene las tablas compartidas por los repositorios en memoria. Los
+// repositorios creados sobre el mismo Store ven los mismos datos y pueden
+// participar en la misma transacción.
+type Store struct {
+	mu    sync.Mutex
+	state *state
+}
+
+// NewStore crea un almacenamiento vacío.
+func NewStore() *Store {
+	return &Store{state: newState()}
+}
+
+// state son los datos del almacenamiento. Las transacciones trabajan sobre el
+// estado vivo y guardan una copia para restaurarla en Rollback.
+type state struct {
+	products     map[int]domain.Product
+	movements    []domain.StockMovement
+	orders       map[int]domain.Order
+	transitions  []domain.OrderStatusTransition
+	amendments   []domain.OrderAmendment
+	reservations map[int]domain.Reservation
+	customers    map[int]domain.Customer
+	sequences    map[string]int
+}
+
+func newState() *state {
+	return &state{
+		products:     map[int]domain.Product{},
+		orders:       map[int]domain.Order{},
+		reservations: map[int]domain.Reservation{},
+		customers:    map[int]domain.Customer{},
+		sequences:    map[string]int{},
+	}
+}
+
+// clone retorna una copia profunda del estado, salvo las secuencias, que se
+// comparten.
+func (s *state) clone() *state {
+	c := newState()
+	for id, p := range s.products {
+		c.products[id] = p
+	}
+	c.movements = append([]domain.StockMovement(nil), s.movements...)
+	for id, o := range s.orders {
+		o.Items = append([]domain.OrderItem(nil), o.Items...)
+		c.orders[id] = o
+	}
+	c.transitions = append([]domain.OrderStatusTransition(nil), s.transitions...)
+	c.amendments = append([]domain.OrderAmendment(nil), s.amendments...)
+	for id, r := range s.reservations {
+		r.Items = append([]domain.ReservationItem(nil), r.Items...)
+		c.reservations[id] = r
+	}
+	for id, cu := range s.customers {
+		cu.Addresses = append([]domain.Address(nil), cu.Addresses...)
+		c.customers[id] = cu
+	}
+	// Las secuencias no forman parte de la copia: como en MySQL, el Rollback no
+	// devuelve los ids consumidos por la transacción
+	c.sequences = s.sequences
+	return c
+}
+
+// nextID retorna el siguiente id autoincremental de la tabla indicada. Los ids
+// consumidos por una transacción revertida no se reutilizan.
+func (s *state) nextID(table string) int {
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+// Tx es una transacción en memoria. Mientras está abierta mantiene el lock del
+// Store, por lo que las transacciones se ejecutan de forma serializable.
+type Tx struct {
+	store    *Store
+	snapshot *state
+	done     bool
+}
+
+// Begin abre una transacción sobre el almacenamiento.
+func (s *Store) Begin() *Tx {
+	s.mu.Lock()
+	return &Tx{store: s, snapshot: s.state.clone()}
+}
+
+// Commit confirma los cambios de la transacción.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	tx.store.mu.Unlock()
+	return nil
+}
+
+// Rollback descarta todos los cambios hechos dentro de la transacción.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	tx.store.state = tx.snapshot
+	tx.store.mu.Unlock()
+	return nil
+}
+
+// state retorna los datos sobre los que opera la transacción.
+func (tx *Tx) state() *state {
+	return tx.store.state
+}
+
+// memoryTx obtiene la *Tx de una transacción abierta por estos repositorios.
+// Recibir una transacción de otro almacenamiento es un error de programación.
+func memoryTx(t domain.Tx) *Tx {
+	return t.(*Tx)
+}
+
+// read ejecuta fn con acceso de solo lectura al estado.
+func (s *Store) read(fn func(st *state)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.state)
+}
+
+// write ejecuta fn dentro de una transacción que se confirma si fn no retorna
+// error y se revierte en caso contrario.
+func (s *Store) write(fn func(tx *Tx) error) error {
+	tx := s.Begin()
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// now retorna la hora actual con la precisión de una columna TIMESTAMP.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestStoreRollbackDiscardsChanges(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	products := NewProductRepository(store)
+
+	product, err := products.CreateProduct(ctx, domain.Product{Name: "A", Price: domain.NewMoney(1000, "USD"), Stock: 10}, domain.StockMovement{Reason: domain.StockMovementRestock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := store.Begin()
+	if err := products.ReduceStockWithTransaction(ctx, tx, product.ID, 4, domain.StockMovement{Reason: domain.StockMovementOrder}); err != nil {
+		t.Fatal(err)
+	}
+	if err := products.ReserveStockWithTransaction(ctx, tx, product.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := products.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stock != 10 || got.Reserved != 0 {
+		t.Errorf("stock %d y reservado %d después del rollback, se esperaba 10 y 0", got.Stock, got.Reserved)
+	}
+
+	page, err := products.ListStockMovements(ctx, product.ID, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Movements) != 1 {
+		t.Errorf("%d movimientos después del rollback, se esperaba solo el inicial", len(page.Movements))
+	}
+
+	if err := tx.Commit(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("commit después del rollback: error %v", err)
+	}
+}
+
+func TestStoreCommitKeepsChanges(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	products := NewProductRepository(store)
+
+	product, err := products.CreateProduct(ctx, domain.Product{Name: "A", Price: domain.NewMoney(1000, "USD"), Stock: 10}, domain.StockMovement{Reason: domain.StockMovementRestock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := store.Begin()
+	if err := products.ReserveStockWithTransaction(ctx, tx, product.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	// El stock retenido no puede reducirse por debajo de lo reservado
+	err = products.ReduceStockWithTransaction(ctx, tx, product.ID, 8, domain.StockMovement{Reason: domain.StockMovementOrder})
+	if !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Errorf("reducir por debajo de lo reservado: error %v, se esperaba ErrInsufficientStock", err)
+	}
+	if err := products.ConsumeReservedStockWithTransaction(ctx, tx, product.ID, 3, domain.StockMovement{Reason: domain.StockMovementOrder}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := products.GetProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stock != 7 || got.Reserved != 0 {
+		t.Errorf("stock %d y reservado %d, se esperaba 7 y 0", got.Stock, got.Reserved)
+	}
+}
+
+func TestStoreRollbackDoesNotReuseIDs(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	products := NewProductRepository(store)
+
+	tx := store.Begin()
+	discarded := tx.state().nextID("products")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := products.CreateProduct(ctx, domain.Product{Name: "A", Price: domain.NewMoney(1000, "USD"), Stock: 1}, domain.StockMovement{Reason: domain.StockMovementRestock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.ID == discarded {
+		t.Errorf("el producto reutilizó el id %d de la transacción revertida", discarded)
+	}
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// sortKind indica cómo se interpreta el valor de una columna de ordenamiento
+// al reconstruirlo desde un cursor.
+type sortKind int
+
+const (
+	sortKindInt sortKind = iota
+	sortKindDecimal
+	sortKindString
+	sortKindTime
+)
+
+// sortColumn describe una columna por la que se permite ordenar un listado.
+type sortColumn struct {
+	Column string
+	Kind   sortKind
+}
+
+// pageCursor es el contenido de un cursor opaco: el valor de la columna de
+// ordenamiento y el id de la última fila devuelta, que desempata valores iguales.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(sort, value string, id int) string {
+	data, _ := json.Marshal(pageCursor{Sort: sort, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor, sort string) (pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageCursor{}, domain.ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return pageCursor{}, domain.ErrInvalidCursor
+	}
+
+	// Un cursor solo es válido para el mismo ordenamiento con el que se generó
+	if c.Sort != sort {
+		return pageCursor{}, domain.ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// cursorArg convierte el valor guardado en el cursor al tipo que espera la columna.
+func (s sortColumn) cursorArg(value string) (any, error) {
+	switch s.Kind {
+	case sortKindInt:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		return v, nil
+	case sortKindDecimal:
+		v, err := domain.ParseMoney(value, "")
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		return v, nil
+	case sortKindTime:
+		v, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// keysetCondition arma la condición que filtra las filas posteriores al cursor
+// según la dirección del ordenamiento.
+func keysetCondition(column, idColumn string, desc bool) string {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	return "(" + column + " " + op + " ? OR (" + column + " = ? AND " + idColumn + " " + op + " ?))"
+}
+
+// parseSort separa el nombre del campo de la dirección; un prefijo "-" indica
+// orden descendente.
+func parseSort(sort string) (field string, desc bool) {
+	if strings.HasPrefix(sort, "-") {
+		return sort[1:], true
+	}
+	return sort, false
+}
+
+// escapeLike escapa los comodines de LIKE para buscar el texto de forma literal.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// placeholders retorna n marcadores "?" separados por comas.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := encodeCursor("-created_at", "2024-05-01T10:00:00Z", 42)
+
+	got, err := decodeCursor(cursor, "-created_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (pageCursor{Sort: "-created_at", Value: "2024-05-01T10:00:00Z", ID: 42}) {
+		t.Errorf("cursor decodificado %+v", got)
+	}
+}
+
+func TestDecodeCursorRejectsInvalidCursors(t *testing.T) {
+	valid := encodeCursor("id", "7", 7)
+	tampered := []byte(valid)
+	tampered[len(tampered)/2] ^= 1
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"otro ordenamiento", valid, "-id"},
+		{"no es base64", "%%%", "id"},
+		{"no es JSON", base64.RawURLEncoding.EncodeToString([]byte("no json")), "id"},
+		{"alterado", string(tampered), "id"},
+	}
+
+	for _, tt := range tests {
+		if _, err := decodeCursor(tt.cursor, tt.sort); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("%s: error %v, se esperaba %v", tt.name, err, domain.ErrInvalidCursor)
+		}
+	}
+}
+
+func TestCursorArgRejectsValuesOfAnotherKind(t *testing.T) {
+	tests := []struct {
+		kind  sortKind
+		value string
+	}{
+		{sortKindInt, "abc"},
+		{sortKindDecimal, "diez"},
+		{sortKindTime, "ayer"},
+	}
+
+	for _, tt := range tests {
+		if _, err := (sortColumn{Column: "c", Kind: tt.kind}).cursorArg(tt.value); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("tipo %d con %q: error %v, se esperaba %v", tt.kind, tt.value, err, domain.ErrInvalidCursor)
+		}
+	}
+
+	if v, err := (sortColumn{Column: "c", Kind: sortKindInt}).cursorArg("12"); err != nil || v != 12 {
+		t.Errorf("entero: %v %v", v, err)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	if got := keysetCondition("o.total_amount", "o.id", false); got != "(o.total_amount > ? OR (o.total_amount = ? AND o.id > ?))" {
+		t.Errorf("ascendente: %s", got)
+	}
+	if got := keysetCondition("o.total_amount", "o.id", true); got != "(o.total_amount < ? OR (o.total_amount = ? AND o.id < ?))" {
+		t.Errorf("descendente: %s", got)
+	}
+}
@@ -0,0 +1,271 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// CustomerRepository maneja las operaciones relacionadas con los clientes en la base de datos.
+type CustomerRepository struct {
+	DB *sql.DB
+}
+
+// NewCustomerRepository crea una nueva instancia de CustomerRepository.
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{DB: db}
+}
+
+// CreateCustomer inserta un cliente con sus direcciones y retorna el registro creado.
+func (r *CustomerRepository) CreateCustomer(ctx context.Context, customer domain.Customer) (domain.Customer, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO customers (name, email, phone, idempotency_key) VALUES (?, ?, ?, ?)",
+		customer.Name, customer.Email, customer.Phone, nullableKey(customer.IdempotencyKey))
+	if err != nil {
+		tx.Rollback()
+		return domain.Customer{}, mapCustomerError(err)
+	}
+
+	customerID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return domain.Customer{}, err
+	}
+
+	if err := insertAddresses(ctx, tx, int(customerID), customer.Addresses); err != nil {
+		tx.Rollback()
+		return domain.Customer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Customer{}, err
+	}
+
+	return r.GetCustomerByID(ctx, int(customerID))
+}
+
+// GetCustomerByID obtiene un cliente por su id junto con sus direcciones.
+func (r *CustomerRepository) GetCustomerByID(ctx context.Context, id int) (domain.Customer, error) {
+	var c domain.Customer
+	err := r.DB.QueryRowContext(ctx, "SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE id = ?", id).
+		Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return domain.Customer{}, notFound(err, domain.ErrCustomerNotFound, id)
+	}
+
+	customers := []domain.Customer{c}
+	if err := r.loadAddresses(ctx, customers); err != nil {
+		return domain.Customer{}, err
+	}
+
+	return customers[0], nil
+}
+
+// GetCustomerByIdempotencyKey obtiene con sus direcciones el cliente creado con la
+// clave de idempotencia indicada.
+func (r *CustomerRepository) GetCustomerByIdempotencyKey(ctx context.Context, key string) (domain.Customer, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, "SELECT id FROM customers WHERE idempotency_key = ?", key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Customer{}, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	return r.GetCustomerByID(ctx, id)
+}
+
+// ListCustomers obtiene una página de clientes ordenados por id, opcionalmente
+// filtrados por nombre o email.
+func (r *CustomerRepository) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error) {
+	const sort = "id"
+
+	query := "SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE 1 = 1"
+	var args []any
+
+	if filter.Search != "" {
+		search := "%" + escapeLike(filter.Search) + "%"
+		query += " AND (name LIKE ? OR email LIKE ?)"
+		args = append(args, search, search)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor, sort)
+		if err != nil {
+			return domain.CustomerPage{}, err
+		}
+		query += " AND id > ?"
+		args = append(args, cursor.ID)
+	}
+
+	// Se pide una fila extra para saber si existe una página siguiente
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return domain.CustomerPage{}, err
+	}
+	defer rows.Close()
+
+	customers := []domain.Customer{}
+	for rows.Next() {
+		var c domain.Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return domain.CustomerPage{}, err
+		}
+		customers = append(customers, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.CustomerPage{}, err
+	}
+
+	var page domain.CustomerPage
+	if len(customers) > filter.Limit {
+		customers = customers[:filter.Limit]
+		last := customers[len(customers)-1]
+		page.NextCursor = encodeCursor(sort, strconv.Itoa(last.ID), last.ID)
+	}
+
+	if len(customers) > 0 {
+		if err := r.loadAddresses(ctx, customers); err != nil {
+			return domain.CustomerPage{}, err
+		}
+	}
+
+	page.Customers = customers
+	return page, nil
+}
+
+// UpdateCustomer actualiza los datos de un cliente. Los valores nil conservan el
+// valor actual; si addresses no es nil reemplaza todas las direcciones.
+func (r *CustomerRepository) UpdateCustomer(ctx context.Context, id int, data domain.UpdateCustomerService) (domain.Customer, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	// Bloquear el cliente y verificar que exista
+	var exists int
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM customers WHERE id = ? FOR UPDATE", id).Scan(&exists); err != nil {
+		tx.Rollback()
+		return domain.Customer{}, notFound(err, domain.ErrCustomerNotFound, id)
+	}
+
+	query := "UPDATE customers SET name = COALESCE(?, name), email = COALESCE(?, email), phone = COALESCE(?, phone) WHERE id = ?"
+	if _, err := tx.ExecContext(ctx, query, data.Name, data.Email, data.Phone, id); err != nil {
+		tx.Rollback()
+		return domain.Customer{}, mapCustomerError(err)
+	}
+
+	if data.Addresses != nil {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM customer_addresses WHERE customer_id = ?", id); err != nil {
+			tx.Rollback()
+			return domain.Customer{}, err
+		}
+
+		if err := insertAddresses(ctx, tx, id, *data.Addresses); err != nil {
+			tx.Rollback()
+			return domain.Customer{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Customer{}, err
+	}
+
+	return r.GetCustomerByID(ctx, id)
+}
+
+// DeleteCustomer elimina un cliente que no tenga órdenes asociadas.
+func (r *CustomerRepository) DeleteCustomer(ctx context.Context, id int) error {
+	var count int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders WHERE customer_id = ?", id).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrCustomerHasOrders
+	}
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM customers WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrCustomerNotFound.With(domain.DetailID(id))
+	}
+
+	return nil
+}
+
+// loadAddresses carga con una sola consulta las direcciones de los clientes recibidos.
+func (r *CustomerRepository) loadAddresses(ctx context.Context, customers []domain.Customer) error {
+	ids := make([]any, len(customers))
+	index := make(map[int]int, len(customers))
+	for i, c := range customers {
+		ids[i] = c.ID
+		index[c.ID] = i
+		customers[i].Addresses = []domain.Address{}
+	}
+
+	query := "SELECT id, customer_id, label, line1, line2, city, state, postal_code, country FROM customer_addresses WHERE customer_id IN (" + placeholders(len(ids)) + ") ORDER BY id"
+	rows, err := r.DB.QueryContext(ctx, query, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a domain.Address
+		if err := rows.Scan(&a.ID, &a.CustomerID, &a.Label, &a.Line1, &a.Line2, &a.City, &a.State, &a.PostalCode, &a.Country); err != nil {
+			return err
+		}
+		i := index[a.CustomerID]
+		customers[i].Addresses = append(customers[i].Addresses, a)
+	}
+
+	return rows.Err()
+}
+
+// insertAddresses inserta las direcciones de un cliente dentro de la transacción.
+func insertAddresses(ctx context.Context, tx *sql.Tx, customerID int, addresses []domain.Address) error {
+	for _, a := range addresses {
+		_, err := tx.ExecContext(ctx, "INSERT INTO customer_addresses (customer_id, label, line1, line2, city, state, postal_code, country) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			customerID, a.Label, a.Line1, a.Line2, a.City, a.State, a.PostalCode, a.Country)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapCustomerError traduce la violación de la clave de idempotencia única a
+// ErrDuplicateIdempotencyKey y la del email único a ErrCustomerEmailTaken.
+func mapCustomerError(err error) error {
+	if isDuplicateIdempotencyKey(err) {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return domain.ErrCustomerEmailTaken
+	}
+	return err
+}
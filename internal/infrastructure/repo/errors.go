@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// mysqlDuplicateEntry es el código de error de MySQL para una clave única repetida.
+const mysqlDuplicateEntry = 1062
+
+// mysqlRowIsReferenced es el código de error de MySQL al eliminar una fila
+// referenciada por una clave foránea con ON DELETE RESTRICT.
+const mysqlRowIsReferenced = 1451
+
+// notFound traduce sql.ErrNoRows al error del dominio notFoundErr para el registro
+// con el id indicado. Los demás errores se retornan sin cambios.
+func notFound(err error, notFoundErr *domain.Error, id int) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFoundErr.With(domain.DetailID(id))
+	}
+	return err
+}
+
+// isDuplicateIdempotencyKey indica si err es la violación del índice único de
+// idempotency_key de alguna tabla.
+func isDuplicateIdempotencyKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry && strings.Contains(mysqlErr.Message, "idempotency_key")
+}
+
+// isRowReferenced indica si err es el rechazo de un DELETE por una clave foránea
+// con ON DELETE RESTRICT.
+func isRowReferenced(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlRowIsReferenced
+}
+
+// mapIdempotencyKeyError traduce la violación de la clave de idempotencia única a
+// domain.ErrDuplicateIdempotencyKey. Los demás errores se retornan sin cambios.
+func mapIdempotencyKeyError(err error) error {
+	if isDuplicateIdempotencyKey(err) {
+		return domain.ErrDuplicateIdempotencyKey
+	}
+	return err
+}
+
+// nullableKey guarda NULL para los registros creados sin clave de idempotencia,
+// de modo que no choquen con el índice único.
+func nullableKey(key string) sql.NullString {
+	return sql.NullString{String: key, Valid: key != ""}
+}
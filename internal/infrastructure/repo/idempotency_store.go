@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/vizardkill/order-management/internal/app"
+	"github.com/vizardkill/order-management/internal/infrastructure/cache"
+)
+
+var _ app.IdempotencyStore = (*IdempotencyStore)(nil)
+
+// IdempotencyStore guarda el estado de las solicitudes con Idempotency-Key en la
+// tabla idempotency_requests. Se usa mientras Redis no está disponible. Las
+// expiraciones se calculan con el reloj de MySQL y los valores se comparan
+// serializados, igual que en Redis.
+type IdempotencyStore struct {
+	DB *sql.DB
+}
+
+// NewIdempotencyStore crea una nueva instancia de IdempotencyStore.
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{DB: db}
+}
+
+// GetIdempotencyKey retorna cache.ErrIdempotencyKeyNotFound si la clave no existe
+// o ya expiró.
+func (s *IdempotencyStore) GetIdempotencyKey(ctx context.Context, key string) (*cache.IdempotencyData, error) {
+	var raw []byte
+	err := s.DB.QueryRowContext(ctx, "SELECT data FROM idempotency_requests WHERE idempotency_key = ? AND expires_at > NOW(3)", key).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, cache.ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data cache.IdempotencyData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CreateIdempotencyKey guarda data solo si la clave no existe o ya expiró.
+func (s *IdempotencyStore) CreateIdempotencyKey(ctx context.Context, key string, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+
+	// La clave vencida se borra para que la nueva solicitud pueda tomarla
+	if _, err := s.DB.ExecContext(ctx, "DELETE FROM idempotency_requests WHERE idempotency_key = ? AND expires_at <= NOW(3)", key); err != nil {
+		return false, err
+	}
+
+	_, err = s.DB.ExecContext(ctx, "INSERT INTO idempotency_requests (idempotency_key, data, expires_at) VALUES (?, ?, NOW(3) + INTERVAL ? MICROSECOND)",
+		key, jsonData, expiration.Microseconds())
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReplaceIdempotencyKey guarda data solo si el valor actual de la clave es old.
+func (s *IdempotencyStore) ReplaceIdempotencyKey(ctx context.Context, key string, old, data cache.IdempotencyData, expiration time.Duration) (bool, error) {
+	oldJSON, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.DB.ExecContext(ctx, "UPDATE idempotency_requests SET data = ?, expires_at = NOW(3) + INTERVAL ? MICROSECOND WHERE idempotency_key = ? AND data = ? AND expires_at > NOW(3)",
+		jsonData, expiration.Microseconds(), key, oldJSON)
+	if err != nil {
+		return false, err
+	}
+	return affectedOne(result)
+}
+
+// ReleaseIdempotencyKey borra la clave solo si su valor actual es old.
+func (s *IdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key string, old cache.IdempotencyData) (bool, error) {
+	oldJSON, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM idempotency_requests WHERE idempotency_key = ? AND data = ? AND expires_at > NOW(3)", key, oldJSON)
+	if err != nil {
+		return false, err
+	}
+	return affectedOne(result)
+}
+
+// affectedOne indica si la sentencia modificó la fila de la clave.
+func affectedOne(result sql.Result) (bool, error) {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
@@ -1,7 +1,13 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vizardkill/order-management/internal/domain"
 )
@@ -14,18 +20,20 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 	return &OrderRepository{DB: db}
 }
 
-func (r *OrderRepository) CreateOrder(order domain.Order, reduceStockFunc func(tx *sql.Tx) error) (domain.Order, error) {
-	tx, err := r.DB.Begin()
+// CreateOrder inserta una orden con sus items e invoca reduceStockFunc con el id de
+// la orden creada dentro de la misma transacción.
+func (r *OrderRepository) CreateOrder(ctx context.Context, order domain.Order, reduceStockFunc func(tx domain.Tx, orderID int) error) (domain.Order, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return domain.Order{}, err
 	}
 
-	query := "INSERT INTO orders (customer_name, total_amount) VALUES (?, ?)"
+	query := "INSERT INTO orders (customer_id, customer_name, total_amount, currency, status, idempotency_key) VALUES (?, ?, ?, ?, ?, ?)"
 
-	result, err := tx.Exec(query, order.CustomerName, order.TotalAmount)
+	result, err := tx.ExecContext(ctx, query, order.CustomerID, order.CustomerName, order.TotalAmount, order.TotalAmount.Currency, domain.OrderStatusPending, nullableKey(order.IdempotencyKey))
 	if err != nil {
 		tx.Rollback()
-		return domain.Order{}, err
+		return domain.Order{}, mapIdempotencyKeyError(err)
 	}
 
 	orderID, err := result.LastInsertId()
@@ -36,8 +44,8 @@ func (r *OrderRepository) CreateOrder(order domain.Order, reduceStockFunc func(t
 
 	// Insertar los items de la orden
 	for _, item := range order.Items {
-		_, err = tx.Exec("INSERT INTO order_items (order_id, product_id, quantity, subtotal) VALUES (?, ?, ?, ?)",
-			orderID, item.ProductID, item.Quantity, item.Subtotal)
+		_, err = tx.ExecContext(ctx, "INSERT INTO order_items (order_id, product_id, product_name, quantity, unit_price, subtotal) VALUES (?, ?, ?, ?, ?, ?)",
+			orderID, item.ProductID, item.ProductName, item.Quantity, item.UnitPrice, item.Subtotal)
 		if err != nil {
 			tx.Rollback()
 			return domain.Order{}, err
@@ -45,7 +53,7 @@ func (r *OrderRepository) CreateOrder(order domain.Order, reduceStockFunc func(t
 	}
 
 	// Reducir el stock de los productos
-	if err := reduceStockFunc(tx); err != nil {
+	if err := reduceStockFunc(tx, int(orderID)); err != nil {
 		tx.Rollback()
 		return domain.Order{}, err
 	}
@@ -56,20 +64,26 @@ func (r *OrderRepository) CreateOrder(order domain.Order, reduceStockFunc func(t
 	}
 
 	// Obtener la orden creada con los items
-	return r.GetOrderWithItemsByID(int(orderID))
+	return r.GetOrderWithItemsByID(ctx, int(orderID))
 }
 
 // Obtener una orden por su ID y sus items
-func (r *OrderRepository) GetOrderWithItemsByID(id int) (domain.Order, error) {
+func (r *OrderRepository) GetOrderWithItemsByID(ctx context.Context, id int) (domain.Order, error) {
 	query := `
         SELECT 
             o.id AS order_id, 
+            o.customer_id, 
             o.customer_name, 
             o.total_amount, 
+            o.currency, 
+            o.status, 
+            o.idempotency_key, 
             o.created_at, 
             o.updated_at, 
             oi.product_id, 
+            oi.product_name, 
             oi.quantity, 
+            oi.unit_price, 
             oi.subtotal
         FROM 
             orders o
@@ -81,13 +95,14 @@ func (r *OrderRepository) GetOrderWithItemsByID(id int) (domain.Order, error) {
             o.id = ?
     `
 
-	rows, err := r.DB.Query(query, id)
+	rows, err := r.DB.QueryContext(ctx, query, id)
 	if err != nil {
 		return domain.Order{}, err
 	}
 	defer rows.Close()
 
 	var order domain.Order
+	var idempotencyKey sql.NullString
 	order.Items = []domain.OrderItem{}
 
 	for rows.Next() {
@@ -97,12 +112,18 @@ func (r *OrderRepository) GetOrderWithItemsByID(id int) (domain.Order, error) {
 		// Escanear los datos de la fila
 		err := rows.Scan(
 			&orderID,
+			&order.CustomerID,
 			&order.CustomerName,
 			&order.TotalAmount,
+			&order.TotalAmount.Currency,
+			&order.Status,
+			&idempotencyKey,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 			&item.ProductID,
+			&item.ProductName,
 			&item.Quantity,
+			&item.UnitPrice,
 			&item.Subtotal,
 		)
 		if err != nil {
@@ -111,17 +132,466 @@ func (r *OrderRepository) GetOrderWithItemsByID(id int) (domain.Order, error) {
 
 		// Agregar el item a la lista si no es nulo
 		if item.ProductID != 0 {
+			item.UnitPrice.Currency = order.TotalAmount.Currency
+			item.Subtotal.Currency = order.TotalAmount.Currency
 			order.Items = append(order.Items, item)
 		}
 
 		// Asignar el ID de la orden (solo una vez)
 		order.ID = orderID
 	}
+	if err := rows.Err(); err != nil {
+		return domain.Order{}, err
+	}
 
 	// Verificar si no se encontró la orden
 	if order.ID == 0 {
-		return domain.Order{}, sql.ErrNoRows
+		return domain.Order{}, domain.ErrOrderNotFound.With(domain.DetailID(id))
 	}
+	order.IdempotencyKey = idempotencyKey.String
 
 	return order, nil
 }
+
+// GetOrderByIdempotencyKey obtiene con sus items la orden creada con la clave de
+// idempotencia indicada.
+func (r *OrderRepository) GetOrderByIdempotencyKey(ctx context.Context, key string) (domain.Order, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, "SELECT id FROM orders WHERE idempotency_key = ?", key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Order{}, domain.ErrOrderNotFound
+	}
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return r.GetOrderWithItemsByID(ctx, id)
+}
+
+// TransitionOrderStatus cambia el estado de una orden validando la tabla de
+// transiciones y registra el cambio en order_status_transitions.
+func (r *OrderRepository) TransitionOrderStatus(ctx context.Context, orderID int, status domain.OrderStatus) (domain.OrderStatusTransition, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	// Bloquear la fila de la orden para que dos transiciones concurrentes no
+	// partan del mismo estado
+	var current domain.OrderStatus
+	err = tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE id = ? FOR UPDATE", orderID).Scan(&current)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, notFound(err, domain.ErrOrderNotFound, orderID)
+	}
+
+	if !current.CanTransitionTo(status) {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, domain.ErrInvalidOrderStatusTransition.With(domain.DetailTransition(current, status))
+	}
+
+	transition, err := r.recordStatusTransition(ctx, tx, orderID, current, status)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	return transition, nil
+}
+
+// CancelOrder marca una orden como cancelada y, dentro de la misma transacción,
+// invoca restoreStockFunc con los items de la orden para devolver su stock.
+func (r *OrderRepository) CancelOrder(ctx context.Context, orderID int, restoreStockFunc func(tx domain.Tx, items []domain.OrderItem) error) (domain.OrderStatusTransition, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	var current domain.OrderStatus
+	err = tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE id = ? FOR UPDATE", orderID).Scan(&current)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, notFound(err, domain.ErrOrderNotFound, orderID)
+	}
+
+	// Las órdenes enviadas, entregadas o ya canceladas no pueden cancelarse
+	if !current.CanTransitionTo(domain.OrderStatusCancelled) {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, domain.ErrInvalidOrderStatusTransition.With(domain.DetailTransition(current, domain.OrderStatusCancelled))
+	}
+
+	items, err := orderItemsWithTransaction(ctx, tx, orderID)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, err
+	}
+
+	// Devolver el stock de los productos
+	if err := restoreStockFunc(tx, items); err != nil {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, err
+	}
+
+	transition, err := r.recordStatusTransition(ctx, tx, orderID, current, domain.OrderStatusCancelled)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderStatusTransition{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	return transition, nil
+}
+
+// AmendOrder bloquea la orden, verifica que aún no haya sido enviada e invoca
+// amendFunc con sus items actuales. Dentro de la misma transacción reemplaza los
+// items por los que retorna amendFunc, actualiza el total y registra la
+// modificación en order_amendments.
+func (r *OrderRepository) AmendOrder(ctx context.Context, orderID int, actor string, amendFunc func(tx domain.Tx, order domain.Order) (domain.Order, error)) (domain.OrderAmendment, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.OrderAmendment{}, err
+	}
+
+	// Bloquear la fila de la orden para que una cancelación o un envío concurrente
+	// no parta de los items anteriores
+	order := domain.Order{ID: orderID}
+	err = tx.QueryRowContext(ctx, "SELECT status, total_amount, currency FROM orders WHERE id = ? FOR UPDATE", orderID).
+		Scan(&order.Status, &order.TotalAmount, &order.TotalAmount.Currency)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, notFound(err, domain.ErrOrderNotFound, orderID)
+	}
+
+	if !order.Status.IsAmendable() {
+		tx.Rollback()
+		return domain.OrderAmendment{}, domain.ErrOrderNotAmendable.With(domain.DetailStatus(order.Status))
+	}
+
+	order.Items, err = orderItemsWithTransaction(ctx, tx, orderID)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, err
+	}
+
+	// Ajustar el stock y calcular los nuevos items
+	amended, err := amendFunc(tx, order)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, err
+	}
+
+	amendment := domain.OrderAmendment{
+		OrderID:       orderID,
+		Actor:         actor,
+		PreviousTotal: order.TotalAmount,
+		NewTotal:      amended.TotalAmount,
+		Changes:       domain.DiffOrderItems(order.Items, amended.Items),
+	}
+	if len(amendment.Changes) == 0 {
+		tx.Rollback()
+		amendment.NewTotal = order.TotalAmount
+		return amendment, nil
+	}
+
+	if err := replaceOrderItems(ctx, tx, orderID, order.Items, amended.Items); err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE orders SET total_amount = ? WHERE id = ?", amended.TotalAmount, orderID); err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, err
+	}
+
+	// Registrar la modificación en el historial
+	amendment.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := tx.ExecContext(ctx, "INSERT INTO order_amendments (order_id, actor, previous_total, new_total, created_at) VALUES (?, ?, ?, ?, ?)",
+		orderID, actor, amendment.PreviousTotal, amendment.NewTotal, amendment.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, err
+	}
+
+	amendmentID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return domain.OrderAmendment{}, err
+	}
+	amendment.ID = int(amendmentID)
+
+	for _, change := range amendment.Changes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO order_amendment_items (amendment_id, product_id, product_name, previous_quantity, new_quantity) VALUES (?, ?, ?, ?, ?)",
+			amendment.ID, change.ProductID, change.ProductName, change.PreviousQuantity, change.NewQuantity)
+		if err != nil {
+			tx.Rollback()
+			return domain.OrderAmendment{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.OrderAmendment{}, err
+	}
+
+	return amendment, nil
+}
+
+// orderItemsWithTransaction obtiene los items de una orden dentro de la
+// transacción recibida.
+func orderItemsWithTransaction(ctx context.Context, tx *sql.Tx, orderID int) ([]domain.OrderItem, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT oi.id, oi.product_id, oi.product_name, oi.quantity, oi.unit_price, oi.subtotal, o.currency
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE oi.order_id = ?
+		ORDER BY oi.id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.OrderItem
+	for rows.Next() {
+		item := domain.OrderItem{OrderID: orderID}
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice, &item.Subtotal, &item.Subtotal.Currency); err != nil {
+			return nil, err
+		}
+		item.UnitPrice.Currency = item.Subtotal.Currency
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// replaceOrderItems guarda los items modificados de una orden: actualiza los que
+// siguen en la orden, inserta los nuevos y elimina los retirados.
+func replaceOrderItems(ctx context.Context, tx *sql.Tx, orderID int, before, after []domain.OrderItem) error {
+	previous := make(map[int]domain.OrderItem, len(before))
+	for _, item := range before {
+		previous[item.ProductID] = item
+	}
+
+	for _, item := range after {
+		existing, ok := previous[item.ProductID]
+		delete(previous, item.ProductID)
+
+		if !ok {
+			_, err := tx.ExecContext(ctx, "INSERT INTO order_items (order_id, product_id, product_name, quantity, unit_price, subtotal) VALUES (?, ?, ?, ?, ?, ?)",
+				orderID, item.ProductID, item.ProductName, item.Quantity, item.UnitPrice, item.Subtotal)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if existing.Quantity == item.Quantity {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE order_items SET quantity = ?, subtotal = ? WHERE id = ?", item.Quantity, item.Subtotal, existing.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Los que quedan ya no están en la orden
+	for _, item := range previous {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM order_items WHERE id = ?", item.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordStatusTransition actualiza el estado de la orden y guarda el registro de
+// la transición dentro de la transacción recibida.
+func (r *OrderRepository) recordStatusTransition(ctx context.Context, tx *sql.Tx, orderID int, from, to domain.OrderStatus) (domain.OrderStatusTransition, error) {
+	if _, err := tx.ExecContext(ctx, "UPDATE orders SET status = ? WHERE id = ?", to, orderID); err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	transition := domain.OrderStatusTransition{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO order_status_transitions (order_id, from_status, to_status, created_at) VALUES (?, ?, ?, ?)",
+		orderID, from, to, transition.CreatedAt)
+	if err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+
+	transitionID, err := result.LastInsertId()
+	if err != nil {
+		return domain.OrderStatusTransition{}, err
+	}
+	transition.ID = int(transitionID)
+
+	return transition, nil
+}
+
+// orderSortColumns son los campos por los que se permite ordenar el listado de órdenes.
+var orderSortColumns = map[string]sortColumn{
+	"id":           {Column: "o.id", Kind: sortKindInt},
+	"created_at":   {Column: "o.created_at", Kind: sortKindTime},
+	"total_amount": {Column: "o.total_amount", Kind: sortKindDecimal},
+}
+
+// ListOrders retorna una página de órdenes que cumplen el filtro usando paginación
+// por cursor (keyset) sobre la columna de ordenamiento y el id. Cuando se solicita,
+// los items de todas las órdenes de la página se cargan con una única consulta.
+func (r *OrderRepository) ListOrders(ctx context.Context, filter domain.OrderFilter) (domain.OrderPage, error) {
+	field, desc := parseSort(filter.Sort)
+	sortCol, ok := orderSortColumns[field]
+	if !ok {
+		return domain.OrderPage{}, fmt.Errorf("campo de ordenamiento no soportado: %s", field)
+	}
+
+	var conditions []string
+	var args []any
+
+	if filter.CustomerID != 0 {
+		conditions = append(conditions, "o.customer_id = ?")
+		args = append(args, filter.CustomerID)
+	}
+	if filter.CustomerName != "" {
+		conditions = append(conditions, "o.customer_name LIKE ?")
+		args = append(args, "%"+escapeLike(filter.CustomerName)+"%")
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "o.created_at >= ?")
+		args = append(args, *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "o.created_at <= ?")
+		args = append(args, *filter.CreatedTo)
+	}
+	if filter.MinTotal != nil {
+		conditions = append(conditions, "o.total_amount >= ?")
+		args = append(args, *filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		conditions = append(conditions, "o.total_amount <= ?")
+		args = append(args, *filter.MaxTotal)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "o.status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return domain.OrderPage{}, err
+		}
+
+		value, err := sortCol.cursorArg(cursor.Value)
+		if err != nil {
+			return domain.OrderPage{}, err
+		}
+
+		conditions = append(conditions, keysetCondition(sortCol.Column, "o.id", desc))
+		args = append(args, value, value, cursor.ID)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	query := "SELECT o.id, o.customer_id, o.customer_name, o.total_amount, o.currency, o.status, o.created_at, o.updated_at FROM orders o"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Se pide una fila extra para saber si existe una página siguiente
+	query += fmt.Sprintf(" ORDER BY %s %s, o.id %s LIMIT ?", sortCol.Column, direction, direction)
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return domain.OrderPage{}, err
+	}
+	defer rows.Close()
+
+	orders := []domain.Order{}
+	for rows.Next() {
+		var order domain.Order
+		if err := rows.Scan(&order.ID, &order.CustomerID, &order.CustomerName, &order.TotalAmount, &order.TotalAmount.Currency, &order.Status, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return domain.OrderPage{}, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.OrderPage{}, err
+	}
+
+	var page domain.OrderPage
+	if len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+		last := orders[len(orders)-1]
+		page.NextCursor = encodeCursor(filter.Sort, orderSortValue(last, field), last.ID)
+	}
+
+	if filter.ExpandItems && len(orders) > 0 {
+		if err := r.loadOrderItems(ctx, orders); err != nil {
+			return domain.OrderPage{}, err
+		}
+	}
+
+	page.Orders = orders
+	return page, nil
+}
+
+// orderSortValue retorna el valor de la columna de ordenamiento de una orden tal
+// como se guarda en el cursor.
+func orderSortValue(order domain.Order, field string) string {
+	switch field {
+	case "created_at":
+		return order.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "total_amount":
+		return order.TotalAmount.String()
+	default:
+		return strconv.Itoa(order.ID)
+	}
+}
+
+// loadOrderItems carga los items de varias órdenes con una sola consulta y los
+// asigna a cada orden.
+func (r *OrderRepository) loadOrderItems(ctx context.Context, orders []domain.Order) error {
+	ids := make([]any, len(orders))
+	index := make(map[int]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+		index[order.ID] = i
+		orders[i].Items = []domain.OrderItem{}
+	}
+
+	query := "SELECT id, order_id, product_id, product_name, quantity, unit_price, subtotal FROM order_items WHERE order_id IN (" + placeholders(len(ids)) + ") ORDER BY id"
+	rows, err := r.DB.QueryContext(ctx, query, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.OrderItem
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice, &item.Subtotal); err != nil {
+			return err
+		}
+		i := index[item.OrderID]
+		item.UnitPrice.Currency = orders[i].TotalAmount.Currency
+		item.Subtotal.Currency = orders[i].TotalAmount.Currency
+		orders[i].Items = append(orders[i].Items, item)
+	}
+
+	return rows.Err()
+}
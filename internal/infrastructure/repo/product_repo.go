@@ -1,8 +1,13 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vizardkill/order-management/internal/domain"
 )
@@ -17,68 +22,172 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{DB: db}
 }
 
-// GetAllProducts obtiene todos los productos de la base de datos.
-// Retorna una lista de productos y un error en caso de que ocurra algún problema.
-func (r *ProductRepository) GetAllProducts() ([]domain.Product, error) {
-	// Consulta SQL para obtener todos los productos
-	query := "SELECT id, name, price, stock, created_at, updated_at FROM products"
+// productSortColumns son los campos por los que se permite ordenar el listado de productos.
+var productSortColumns = map[string]sortColumn{
+	"id":         {Column: "id", Kind: sortKindInt},
+	"name":       {Column: "name", Kind: sortKindString},
+	"price":      {Column: "price", Kind: sortKindDecimal},
+	"stock":      {Column: "stock", Kind: sortKindInt},
+	"updated_at": {Column: "updated_at", Kind: sortKindTime},
+}
 
-	// Ejecutar la consulta
-	rows, err := r.DB.Query(query)
+// ListProducts obtiene una página de productos que cumplen el filtro usando
+// paginación por cursor, junto con el total de productos que cumplen el filtro.
+func (r *ProductRepository) ListProducts(ctx context.Context, filter domain.ProductFilter) (domain.ProductPage, error) {
+	field, desc := parseSort(filter.Sort)
+	sortCol, ok := productSortColumns[field]
+	if !ok {
+		return domain.ProductPage{}, fmt.Errorf("campo de ordenamiento no soportado: %s", field)
+	}
+
+	var conditions []string
+	var args []any
+
+	if filter.Search != "" {
+		conditions = append(conditions, "name LIKE ?")
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			conditions = append(conditions, "stock - reserved > 0")
+		} else {
+			conditions = append(conditions, "stock - reserved <= 0")
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// El total no depende del cursor, solo de los filtros
+	var page domain.ProductPage
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+where, args...).Scan(&page.Total); err != nil {
+		return domain.ProductPage{}, errors.New("error al contar los productos: " + err.Error())
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return domain.ProductPage{}, err
+		}
+
+		value, err := sortCol.cursorArg(cursor.Value)
+		if err != nil {
+			return domain.ProductPage{}, err
+		}
+
+		conditions = append(conditions, keysetCondition(sortCol.Column, "id", desc))
+		args = append(args, value, value, cursor.ID)
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	// Se pide una fila extra para saber si existe una página siguiente
+	query := "SELECT id, name, price, currency, stock, reserved, created_at, updated_at FROM products" + where +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", sortCol.Column, direction, direction)
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, errors.New("error al ejecutar la consulta para obtener productos: " + err.Error())
+		return domain.ProductPage{}, errors.New("error al ejecutar la consulta para obtener productos: " + err.Error())
 	}
 	defer rows.Close()
 
-	var products []domain.Product
-
-	// Iterar sobre las filas del resultado
+	products := []domain.Product{}
 	for rows.Next() {
 		var p domain.Product
-
-		// Escanear los datos de la fila en la estructura Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CreatedAt, &p.UpdatedAt); err != nil {
-			// Loguear el error y continuar con la siguiente fila
-			// Esto asegura que un error en una fila no detenga el proceso completo
-			continue
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Price.Currency, &p.Stock, &p.Reserved, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return domain.ProductPage{}, errors.New("error al leer el producto: " + err.Error())
 		}
-
-		// Agregar el producto
 		products = append(products, p)
 	}
 
-	// Verificar si ocurrió algún error durante la iteración
 	if err := rows.Err(); err != nil {
-		return nil, errors.New("error al iterar sobre los resultados: " + err.Error())
+		return domain.ProductPage{}, errors.New("error al iterar sobre los resultados: " + err.Error())
+	}
+
+	if len(products) > filter.Limit {
+		products = products[:filter.Limit]
+		last := products[len(products)-1]
+		page.NextCursor = encodeCursor(filter.Sort, productSortValue(last, field), last.ID)
 	}
 
-	// Retornar la lista de productos
-	return products, nil
+	page.Products = products
+	return page, nil
 }
 
-func (r *ProductRepository) GetProductByID(id int) (domain.Product, error) {
+// productSortValue retorna el valor de la columna de ordenamiento de un producto
+// tal como se guarda en el cursor.
+func productSortValue(p domain.Product, field string) string {
+	switch field {
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price.String()
+	case "stock":
+		return strconv.Itoa(p.Stock)
+	case "updated_at":
+		return p.UpdatedAt
+	default:
+		return strconv.Itoa(p.ID)
+	}
+}
+
+func (r *ProductRepository) GetProductByID(ctx context.Context, id int) (domain.Product, error) {
 	// Consulta SQL para obtener un producto por su ID
-	query := "SELECT id, name, price, stock, created_at, updated_at FROM products WHERE id = ?"
+	query := "SELECT id, name, price, currency, stock, reserved, created_at, updated_at FROM products WHERE id = ?"
 
 	// Ejecutar la consulta
-	row := r.DB.QueryRow(query, id)
+	row := r.DB.QueryRowContext(ctx, query, id)
 
 	// Crear una estructura Product para almacenar el resultado
 	var p domain.Product
 
 	// Escanear los datos de la fila en la estructura Product
-	if err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CreatedAt, &p.UpdatedAt); err != nil {
-		return domain.Product{}, errors.New("error al obtener el producto: " + err.Error())
+	if err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Price.Currency, &p.Stock, &p.Reserved, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return domain.Product{}, notFound(err, domain.ErrProductNotFound, id)
 	}
 
 	// Retornar el producto
 	return p, nil
 }
 
-// ReduceStock reduce el stock de un producto en la base de datos.
-func (r *ProductRepository) ReduceStockWithTransaction(tx *sql.Tx, productID int, quantity int) error {
-	query := "UPDATE products SET stock = stock - ? WHERE id = ?"
-	result, err := tx.Exec(query, quantity, productID)
+// GetProductByIdempotencyKey obtiene el producto creado con la clave de
+// idempotencia indicada.
+func (r *ProductRepository) GetProductByIdempotencyKey(ctx context.Context, key string) (domain.Product, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, "SELECT id FROM products WHERE idempotency_key = ?", key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Product{}, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return r.GetProductByID(ctx, id)
+}
+
+// ReduceStock reduce el stock de un producto en la base de datos. Solo se
+// descuenta del stock disponible, es decir, el que no está retenido por reservas.
+// El cambio se registra en el historial con el motivo y actor de movement.
+func (r *ProductRepository) ReduceStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int, movement domain.StockMovement) error {
+	tx := sqlTx(t)
+
+	query := "UPDATE products SET stock = stock - ? WHERE id = ? AND stock - reserved >= ?"
+	result, err := tx.ExecContext(ctx, query, quantity, productID, quantity)
 	if err != nil {
 		return err
 	}
@@ -89,15 +198,327 @@ func (r *ProductRepository) ReduceStockWithTransaction(tx *sql.Tx, productID int
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("no se pudo reducir el stock, stock insuficiente o producto no encontrado")
+		return domain.ErrInsufficientStock.With(domain.DetailProductUnavailable(productID))
+	}
+
+	_, err = r.recordStockMovement(ctx, tx, productID, -quantity, movement)
+	return err
+}
+
+// IncreaseStockWithTransaction incrementa el stock de un producto dentro de una
+// transacción y registra el movimiento en el historial.
+func (r *ProductRepository) IncreaseStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int, movement domain.StockMovement) error {
+	tx := sqlTx(t)
+
+	query := "UPDATE products SET stock = stock + ? WHERE id = ?"
+	result, err := tx.ExecContext(ctx, query, quantity, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrProductNotFound.With(domain.DetailID(productID))
+	}
+
+	_, err = r.recordStockMovement(ctx, tx, productID, quantity, movement)
+	return err
+}
+
+// ReserveStockWithTransaction retiene stock disponible de un producto para una
+// reserva sin reducir su stock físico.
+func (r *ProductRepository) ReserveStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int) error {
+	tx := sqlTx(t)
+
+	query := "UPDATE products SET reserved = reserved + ? WHERE id = ? AND stock - reserved >= ?"
+	result, err := tx.ExecContext(ctx, query, quantity, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrInsufficientStock.With(domain.DetailProductUnavailable(productID))
 	}
 
 	return nil
 }
 
-func (r *ProductRepository) UpdateStock(productID int, quantity int) error {
-	query := "UPDATE products SET stock = ? WHERE id = ?"
-	result, err := r.DB.Exec(query, quantity, productID)
+// ReleaseReservedStockWithTransaction devuelve al stock disponible la cantidad
+// retenida por una reserva que se libera o expira.
+func (r *ProductRepository) ReleaseReservedStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int) error {
+	tx := sqlTx(t)
+
+	query := "UPDATE products SET reserved = reserved - ? WHERE id = ? AND reserved >= ?"
+	result, err := tx.ExecContext(ctx, query, quantity, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("no se pudo liberar el stock reservado o producto no encontrado")
+	}
+
+	return nil
+}
+
+// ConsumeReservedStockWithTransaction convierte stock reservado en stock vendido:
+// reduce tanto el stock físico como la cantidad reservada y registra el movimiento.
+func (r *ProductRepository) ConsumeReservedStockWithTransaction(ctx context.Context, t domain.Tx, productID int, quantity int, movement domain.StockMovement) error {
+	tx := sqlTx(t)
+
+	query := "UPDATE products SET stock = stock - ?, reserved = reserved - ? WHERE id = ? AND reserved >= ? AND stock >= ?"
+	result, err := tx.ExecContext(ctx, query, quantity, quantity, productID, quantity, quantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("no se pudo consumir el stock reservado o producto no encontrado")
+	}
+
+	_, err = r.recordStockMovement(ctx, tx, productID, -quantity, movement)
+	return err
+}
+
+// LockProductsWithTransaction bloquea las filas de los productos con SELECT ...
+// FOR UPDATE hasta que termine la transacción. Los ids deben venir ordenados para
+// que dos transacciones bloqueen las filas en el mismo orden.
+func (r *ProductRepository) LockProductsWithTransaction(ctx context.Context, t domain.Tx, productIDs []int) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	tx := sqlTx(t)
+
+	args := make([]any, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+
+	query := "SELECT id FROM products WHERE id IN (" + placeholders(len(productIDs)) + ") ORDER BY id FOR UPDATE"
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// UpdateStock reemplaza el stock de un producto. El nuevo valor no puede ser
+// menor que la cantidad retenida por reservas activas. La diferencia con el stock
+// anterior se registra en el historial.
+func (r *ProductRepository) UpdateStock(ctx context.Context, productID int, quantity int, movement domain.StockMovement) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var current, reserved int
+	err = tx.QueryRowContext(ctx, "SELECT stock, reserved FROM products WHERE id = ? FOR UPDATE", productID).Scan(&current, &reserved)
+	if err != nil {
+		tx.Rollback()
+		return notFound(err, domain.ErrProductNotFound, productID)
+	}
+
+	if quantity < reserved {
+		tx.Rollback()
+		return domain.ErrInsufficientStock.With(domain.DetailBelowReserved(reserved))
+	}
+
+	// Sin cambios no hay movimiento que registrar
+	if quantity == current {
+		return tx.Rollback()
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE products SET stock = ? WHERE id = ?", quantity, productID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := r.recordStockMovement(ctx, tx, productID, quantity-current, movement); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AdjustStock suma delta (positivo o negativo) al stock de un producto con una
+// única sentencia SQL, de modo que el resultado no depende de lecturas previas del
+// cliente. Rechaza el ajuste si el stock quedaría por debajo de lo reservado (y
+// por lo tanto nunca por debajo de cero). Retorna el movimiento registrado, cuyo
+// Balance es el nuevo stock.
+func (r *ProductRepository) AdjustStock(ctx context.Context, productID int, delta int, movement domain.StockMovement) (domain.StockMovement, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	query := "UPDATE products SET stock = stock + ? WHERE id = ? AND stock + ? >= reserved"
+	result, err := tx.ExecContext(ctx, query, delta, productID, delta)
+	if err != nil {
+		tx.Rollback()
+		return domain.StockMovement{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return domain.StockMovement{}, err
+	}
+
+	if rowsAffected == 0 {
+		tx.Rollback()
+
+		// Distinguir un producto inexistente de un ajuste que dejaría el stock negativo
+		if _, err := r.GetProductByID(ctx, productID); err != nil {
+			return domain.StockMovement{}, err
+		}
+		return domain.StockMovement{}, domain.ErrInsufficientStock.With(domain.DetailStockAdjustment(delta, productID))
+	}
+
+	applied, err := r.recordStockMovement(ctx, tx, productID, delta, movement)
+	if err != nil {
+		tx.Rollback()
+		return domain.StockMovement{}, mapIdempotencyKeyError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	return applied, nil
+}
+
+// GetStockMovementByIdempotencyKey obtiene el movimiento registrado con la clave
+// de idempotencia indicada.
+func (r *ProductRepository) GetStockMovementByIdempotencyKey(ctx context.Context, key string) (domain.StockMovement, error) {
+	var m domain.StockMovement
+	var orderID sql.NullInt64
+	err := r.DB.QueryRowContext(ctx, "SELECT id, product_id, delta, balance, reason, actor, order_id, note, created_at FROM stock_movements WHERE idempotency_key = ?", key).
+		Scan(&m.ID, &m.ProductID, &m.Delta, &m.Balance, &m.Reason, &m.Actor, &orderID, &m.Note, &m.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.StockMovement{}, domain.ErrStockMovementNotFound
+	}
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	if orderID.Valid {
+		id := int(orderID.Int64)
+		m.OrderID = &id
+	}
+	m.IdempotencyKey = key
+
+	return m, nil
+}
+
+// CreateProduct inserta un nuevo producto y retorna el registro creado. El stock
+// inicial se registra en el historial como un reabastecimiento.
+func (r *ProductRepository) CreateProduct(ctx context.Context, product domain.Product, movement domain.StockMovement) (domain.Product, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	query := "INSERT INTO products (name, price, currency, stock, idempotency_key) VALUES (?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, query, product.Name, product.Price, product.Price.Currency, product.Stock, nullableKey(product.IdempotencyKey))
+	if err != nil {
+		tx.Rollback()
+		if isDuplicateIdempotencyKey(err) {
+			return domain.Product{}, domain.ErrDuplicateIdempotencyKey
+		}
+		return domain.Product{}, errors.New("error al crear el producto: " + err.Error())
+	}
+
+	productID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return domain.Product{}, err
+	}
+
+	if product.Stock > 0 {
+		if _, err := r.recordStockMovement(ctx, tx, int(productID), product.Stock, movement); err != nil {
+			tx.Rollback()
+			return domain.Product{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Product{}, err
+	}
+
+	return r.GetProductByID(ctx, int(productID))
+}
+
+// UpdateProduct actualiza el nombre y/o el precio de un producto. Los valores nil
+// conservan el valor actual de la columna. Un precio sin moneda conserva la
+// moneda actual del producto.
+func (r *ProductRepository) UpdateProduct(ctx context.Context, productID int, name *string, price *domain.Money) (domain.Product, error) {
+	var currency *string
+	if price != nil && price.Currency != "" {
+		currency = &price.Currency
+	}
+
+	query := "UPDATE products SET name = COALESCE(?, name), price = COALESCE(?, price), currency = COALESCE(?, currency) WHERE id = ?"
+	if _, err := r.DB.ExecContext(ctx, query, name, price, currency, productID); err != nil {
+		return domain.Product{}, errors.New("error al actualizar el producto: " + err.Error())
+	}
+
+	// MySQL no reporta filas afectadas cuando los valores no cambian, por lo que
+	// la existencia del producto se verifica leyéndolo nuevamente.
+	return r.GetProductByID(ctx, productID)
+}
+
+// DeleteProduct elimina un producto que no haya sido incluido en ninguna orden ni
+// tenga movimientos de stock.
+func (r *ProductRepository) DeleteProduct(ctx context.Context, productID int) error {
+	// order_items referencia a products con ON DELETE CASCADE, así que eliminar un
+	// producto vendido borraría también el historial de las órdenes.
+	var count int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM order_items WHERE product_id = ?", productID).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrProductHasOrders
+	}
+
+	// stock_movements referencia a products con ON DELETE RESTRICT; la violación
+	// se traduce por si se registró un movimiento después de la consulta
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM stock_movements WHERE product_id = ?", productID).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrProductHasStockMovements
+	}
+
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM products WHERE id = ?", productID)
+	if isRowReferenced(err) {
+		return domain.ErrProductHasStockMovements
+	}
 	if err != nil {
 		return err
 	}
@@ -108,8 +529,98 @@ func (r *ProductRepository) UpdateStock(productID int, quantity int) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("no se pudo actualizar el stock o producto no encontrado")
+		return domain.ErrProductNotFound.With(domain.DetailID(productID))
 	}
 
 	return nil
 }
+
+// recordStockMovement registra en stock_movements un cambio de stock ya aplicado
+// dentro de la transacción, junto con el saldo resultante del producto, y retorna
+// el movimiento registrado.
+func (r *ProductRepository) recordStockMovement(ctx context.Context, tx *sql.Tx, productID int, delta int, movement domain.StockMovement) (domain.StockMovement, error) {
+	// La fila del producto ya está bloqueada por la actualización previa, así que
+	// el saldo leído es exactamente el que dejó este cambio
+	var balance int
+	if err := tx.QueryRowContext(ctx, "SELECT stock FROM products WHERE id = ?", productID).Scan(&balance); err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	if movement.Actor == "" {
+		movement.Actor = domain.AnonymousActor
+	}
+	movement.ProductID = productID
+	movement.Delta = delta
+	movement.Balance = balance
+	movement.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := "INSERT INTO stock_movements (product_id, delta, balance, reason, actor, order_id, note, idempotency_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, query, productID, delta, balance, movement.Reason, movement.Actor, movement.OrderID, movement.Note, nullableKey(movement.IdempotencyKey), movement.CreatedAt)
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+
+	movementID, err := result.LastInsertId()
+	if err != nil {
+		return domain.StockMovement{}, err
+	}
+	movement.ID = int(movementID)
+
+	return movement, nil
+}
+
+// ListStockMovements obtiene el historial de stock de un producto, del movimiento
+// más reciente al más antiguo, paginado por cursor.
+func (r *ProductRepository) ListStockMovements(ctx context.Context, productID int, limit int, cursor string) (domain.StockMovementPage, error) {
+	const sort = "-id"
+
+	query := "SELECT id, product_id, delta, balance, reason, actor, order_id, note, created_at FROM stock_movements WHERE product_id = ?"
+	args := []any{productID}
+
+	if cursor != "" {
+		c, err := decodeCursor(cursor, sort)
+		if err != nil {
+			return domain.StockMovementPage{}, err
+		}
+		query += " AND id < ?"
+		args = append(args, c.ID)
+	}
+
+	// Se pide una fila extra para saber si existe una página siguiente
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return domain.StockMovementPage{}, errors.New("error al obtener el historial de stock: " + err.Error())
+	}
+	defer rows.Close()
+
+	movements := []domain.StockMovement{}
+	for rows.Next() {
+		var m domain.StockMovement
+		var orderID sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.Delta, &m.Balance, &m.Reason, &m.Actor, &orderID, &m.Note, &m.CreatedAt); err != nil {
+			return domain.StockMovementPage{}, err
+		}
+		if orderID.Valid {
+			id := int(orderID.Int64)
+			m.OrderID = &id
+		}
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.StockMovementPage{}, err
+	}
+
+	var page domain.StockMovementPage
+	if len(movements) > limit {
+		movements = movements[:limit]
+		last := movements[len(movements)-1]
+		page.NextCursor = encodeCursor(sort, strconv.Itoa(last.ID), last.ID)
+	}
+
+	page.Movements = movements
+	return page, nil
+}
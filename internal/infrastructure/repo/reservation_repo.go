@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// ReservationRepository maneja las reservas de stock en la base de datos.
+type ReservationRepository struct {
+	DB *sql.DB
+}
+
+// NewReservationRepository crea una nueva instancia de ReservationRepository.
+func NewReservationRepository(db *sql.DB) *ReservationRepository {
+	return &ReservationRepository{DB: db}
+}
+
+// queryer agrupa los métodos de consulta comunes a *sql.DB y *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// CreateReservation inserta una reserva activa con sus items e invoca
+// reserveStockFunc dentro de la misma transacción para retener el stock.
+func (r *ReservationRepository) CreateReservation(ctx context.Context, reservation domain.Reservation, reserveStockFunc func(tx domain.Tx) error) (domain.Reservation, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO stock_reservations (customer_name, status, expires_at, idempotency_key) VALUES (?, ?, ?, ?)",
+		reservation.CustomerName, domain.ReservationStatusActive, reservation.ExpiresAt, nullableKey(reservation.IdempotencyKey))
+	if err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, mapIdempotencyKeyError(err)
+	}
+
+	reservationID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, err
+	}
+
+	for _, item := range reservation.Items {
+		_, err = tx.ExecContext(ctx, "INSERT INTO stock_reservation_items (reservation_id, product_id, quantity) VALUES (?, ?, ?)",
+			reservationID, item.ProductID, item.Quantity)
+		if err != nil {
+			tx.Rollback()
+			return domain.Reservation{}, err
+		}
+	}
+
+	// Retener el stock de los productos
+	if err := reserveStockFunc(tx); err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return r.GetReservationByID(ctx, int(reservationID))
+}
+
+// GetReservationByID obtiene una reserva por su id junto con sus items.
+func (r *ReservationRepository) GetReservationByID(ctx context.Context, id int) (domain.Reservation, error) {
+	return getReservation(ctx, r.DB, id, false)
+}
+
+// GetReservationByIdempotencyKey obtiene con sus items la reserva creada con la
+// clave de idempotencia indicada.
+func (r *ReservationRepository) GetReservationByIdempotencyKey(ctx context.Context, key string) (domain.Reservation, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, "SELECT id FROM stock_reservations WHERE idempotency_key = ?", key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Reservation{}, domain.ErrReservationNotFound
+	}
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return r.GetReservationByID(ctx, id)
+}
+
+// ReleaseReservation cierra una reserva activa con el estado indicado (released o
+// expired) e invoca releaseStockFunc con sus items dentro de la misma transacción
+// para devolver el stock retenido.
+func (r *ReservationRepository) ReleaseReservation(ctx context.Context, id int, status domain.ReservationStatus, releaseStockFunc func(tx domain.Tx, items []domain.ReservationItem) error) (domain.Reservation, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	reservation, err := getReservation(ctx, tx, id, true)
+	if err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, err
+	}
+
+	if reservation.Status != domain.ReservationStatusActive {
+		tx.Rollback()
+		return domain.Reservation{}, domain.ErrReservationNotActive.With(domain.DetailStatus(reservation.Status))
+	}
+
+	// Devolver el stock retenido
+	if err := releaseStockFunc(tx, reservation.Items); err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE stock_reservations SET status = ? WHERE id = ?", status, id); err != nil {
+		tx.Rollback()
+		return domain.Reservation{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Reservation{}, err
+	}
+
+	reservation.Status = status
+	return reservation, nil
+}
+
+// ConsumeReservationWithTransaction marca una reserva como confirmada por la orden
+// indicada. Bloquea la fila de la reserva y verifica que siga activa y sin expirar
+// en el instante now.
+func (r *ReservationRepository) ConsumeReservationWithTransaction(ctx context.Context, t domain.Tx, id int, orderID int, now time.Time) (domain.Reservation, error) {
+	tx := sqlTx(t)
+
+	reservation, err := getReservation(ctx, tx, id, true)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	if !reservation.IsActiveAt(now) {
+		return domain.Reservation{}, domain.ErrReservationNotActive.With(domain.DetailReservation(id))
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE stock_reservations SET status = ?, order_id = ? WHERE id = ?",
+		domain.ReservationStatusConfirmed, orderID, id); err != nil {
+		return domain.Reservation{}, err
+	}
+
+	reservation.Status = domain.ReservationStatusConfirmed
+	reservation.OrderID = &orderID
+	return reservation, nil
+}
+
+// ListExpiredReservationIDs retorna hasta limit reservas activas cuyo vencimiento
+// ya pasó en el instante now.
+func (r *ReservationRepository) ListExpiredReservationIDs(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT id FROM stock_reservations WHERE status = ? AND expires_at <= ? ORDER BY expires_at LIMIT ?",
+		domain.ReservationStatusActive, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// getReservation lee una reserva y sus items. Con forUpdate la fila de la reserva
+// queda bloqueada hasta el fin de la transacción.
+func getReservation(ctx context.Context, q queryer, id int, forUpdate bool) (domain.Reservation, error) {
+	query := "SELECT id, customer_name, status, expires_at, order_id, created_at, updated_at FROM stock_reservations WHERE id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var reservation domain.Reservation
+	var orderID sql.NullInt64
+	err := q.QueryRowContext(ctx, query, id).Scan(
+		&reservation.ID,
+		&reservation.CustomerName,
+		&reservation.Status,
+		&reservation.ExpiresAt,
+		&orderID,
+		&reservation.CreatedAt,
+		&reservation.UpdatedAt,
+	)
+	if err != nil {
+		return domain.Reservation{}, notFound(err, domain.ErrReservationNotFound, id)
+	}
+
+	if orderID.Valid {
+		id := int(orderID.Int64)
+		reservation.OrderID = &id
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT id, reservation_id, product_id, quantity FROM stock_reservation_items WHERE reservation_id = ? ORDER BY id", id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer rows.Close()
+
+	reservation.Items = []domain.ReservationItem{}
+	for rows.Next() {
+		var item domain.ReservationItem
+		if err := rows.Scan(&item.ID, &item.ReservationID, &item.ProductID, &item.Quantity); err != nil {
+			return domain.Reservation{}, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return reservation, nil
+}
@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"database/sql"
+
+	"github.com/vizardkill/order-management/internal/domain"
+)
+
+// sqlTx obtiene la *sql.Tx de una transacción abierta por estos repositorios.
+// Recibir una transacción de otro almacenamiento es un error de programación.
+func sqlTx(t domain.Tx) *sql.Tx {
+	return t.(*sql.Tx)
+}